@@ -5,11 +5,14 @@ go 1.24.6
 require (
 	github.com/matryer/is v1.4.1
 	github.com/prometheus/client_golang v1.23.0
+	go.yaml.in/yaml/v3 v3.0.4
 )
 
 require (
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/prometheus/client_model v0.6.2 // indirect
 	github.com/prometheus/common v0.65.0 // indirect
@@ -0,0 +1,148 @@
+package livestatus
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPort is the conventional Livestatus TCP port, used when a tcp:// or
+// tls:// URI omits it.
+const defaultPort = "6557"
+
+// NewLiveStatusConfigURI creates a configuration with the defaults of
+// NewLiveStatusConfig and the endpoint described by uri (see SetAddress).
+func NewLiveStatusConfigURI(uri string) (*LiveStatusConfig, error) {
+	cfg := NewLiveStatusConfig("")
+	if err := cfg.SetAddress(uri); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SetAddress sets Network, Address and the TLS fields from an address URI:
+//
+//	unix:///omd/sites/prod/tmp/run/live
+//	tcp://monitor:6557
+//	tcp://[::1]:6557
+//	tls://monitor:6557?ca=/etc/ssl/site-ca.pem&servername=monitor.example.com
+//
+// tls:// accepts the query parameters ca, cert, key, servername and insecure,
+// which fill CAFile, CertFile, KeyFile, ServerName and InsecureSkipVerify. The
+// port defaults to 6557. An address without a scheme is kept as is and its
+// network is inferred as before: host:port is TCP, anything else a socket path.
+func (c *LiveStatusConfig) SetAddress(uri string) error {
+	if !strings.Contains(uri, "://") {
+		c.Network = inferNetwork(uri)
+		c.Address = uri
+		return nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", uri, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		if u.Host != "" {
+			return fmt.Errorf("invalid address %q: unix socket path must be absolute (unix:///path)", uri)
+		}
+		if u.Path == "" {
+			return fmt.Errorf("invalid address %q: missing socket path", uri)
+		}
+		if u.RawQuery != "" {
+			return fmt.Errorf("invalid address %q: unix addresses take no parameters", uri)
+		}
+		c.Network, c.Address, c.UseTLS = "unix", u.Path, false
+		return nil
+	case "tcp", "tls":
+		host := u.Hostname()
+		if host == "" {
+			return fmt.Errorf("invalid address %q: missing host", uri)
+		}
+		if u.Path != "" && u.Path != "/" {
+			return fmt.Errorf("invalid address %q: unexpected path %q", uri, u.Path)
+		}
+		port := u.Port()
+		if port == "" {
+			port = defaultPort
+		}
+		if u.Scheme == "tcp" && u.RawQuery != "" {
+			return fmt.Errorf("invalid address %q: tcp addresses take no parameters", uri)
+		}
+		// Parameters are applied to a copy so a bad one leaves c untouched.
+		next := *c
+		if u.Scheme == "tls" {
+			if err := next.setTLSParams(u.Query()); err != nil {
+				return fmt.Errorf("invalid address %q: %w", uri, err)
+			}
+		}
+		next.Network, next.Address, next.UseTLS = "tcp", net.JoinHostPort(host, port), u.Scheme == "tls"
+		*c = next
+		return nil
+	default:
+		return fmt.Errorf("invalid address %q: unsupported scheme %q (want unix, tcp or tls)", uri, u.Scheme)
+	}
+}
+
+// setTLSParams applies the query parameters of a tls:// URI.
+func (c *LiveStatusConfig) setTLSParams(params url.Values) error {
+	for key, values := range params {
+		value := values[len(values)-1]
+		switch key {
+		case "ca":
+			c.CAFile = value
+		case "cert":
+			c.CertFile = value
+		case "key":
+			c.KeyFile = value
+		case "servername":
+			c.ServerName = value
+		case "insecure":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid insecure value %q", value)
+			}
+			c.InsecureSkipVerify = b
+		default:
+			return fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+	return nil
+}
+
+// endpoint returns the network and address to dial. A URI left in Address
+// (e.g. by NewLiveStatusConfig or a struct literal) is parsed here, so a copy
+// with the parsed fields is returned when needed.
+func (c *LiveStatusConfig) endpoint() (*LiveStatusConfig, string, error) {
+	if c.Network == "" && strings.Contains(c.Address, "://") {
+		cp := *c
+		if err := cp.SetAddress(c.Address); err != nil {
+			return nil, "", err
+		}
+		c = &cp
+	}
+	switch c.Network {
+	case "":
+		return c, inferNetwork(c.Address), nil
+	case "unix", "tcp":
+		return c, c.Network, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported network %q (want unix or tcp)", c.Network)
+	}
+}
+
+// inferNetwork guesses the network of a bare address. Paths are recognised by
+// their leading slash or dot, so socket paths containing colons still work;
+// otherwise an address that splits into host and port (including [v6]:port)
+// is TCP.
+func inferNetwork(addr string) string {
+	if strings.HasPrefix(addr, "/") || strings.HasPrefix(addr, ".") {
+		return "unix"
+	}
+	if _, port, err := net.SplitHostPort(addr); err == nil && port != "" {
+		return "tcp"
+	}
+	return "unix"
+}
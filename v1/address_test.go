@@ -0,0 +1,141 @@
+package livestatus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSetAddress(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		uri     string
+		network string
+		address string
+		tls     bool
+	}{
+		{"unix:///omd/sites/prod/tmp/run/live", "unix", "/omd/sites/prod/tmp/run/live", false},
+		{"tcp://monitor:6557", "tcp", "monitor:6557", false},
+		{"tcp://monitor", "tcp", "monitor:6557", false},
+		{"tcp://[::1]:6557", "tcp", "[::1]:6557", false},
+		{"tcp://[fe80::1]", "tcp", "[fe80::1]:6557", false},
+		{"tls://monitor:6558/", "tcp", "monitor:6558", true},
+		// Bare addresses keep working.
+		{"localhost:6557", "tcp", "localhost:6557", false},
+		{"[::1]:6557", "tcp", "[::1]:6557", false},
+		{"/var/run/livestatus.sock", "unix", "/var/run/livestatus.sock", false},
+		{"/omd/sites/a:b/tmp/run/live", "unix", "/omd/sites/a:b/tmp/run/live", false},
+		{"./live", "unix", "./live", false},
+		{"live", "unix", "live", false},
+	}
+	for _, tt := range tests {
+		cfg := NewLiveStatusConfig("")
+		is.NoErr(cfg.SetAddress(tt.uri))
+		is.Equal(cfg.Network, tt.network) // network of tt.uri
+		is.Equal(cfg.Address, tt.address) // address of tt.uri
+		is.Equal(cfg.UseTLS, tt.tls)      // TLS of tt.uri
+	}
+}
+
+func TestSetAddressTLSParams(t *testing.T) {
+	is := is.New(t)
+
+	cfg, err := NewLiveStatusConfigURI("tls://monitor:6557?ca=/etc/ca.pem&servername=monitor.example.com&cert=/c.pem&key=/k.pem&insecure=true")
+	is.NoErr(err)
+	is.Equal(cfg.Address, "monitor:6557")
+	is.True(cfg.UseTLS)
+	is.Equal(cfg.CAFile, "/etc/ca.pem")
+	is.Equal(cfg.ServerName, "monitor.example.com")
+	is.Equal(cfg.CertFile, "/c.pem")
+	is.Equal(cfg.KeyFile, "/k.pem")
+	is.True(cfg.InsecureSkipVerify)
+	is.Equal(cfg.ReadTimeout, NewLiveStatusConfig("").ReadTimeout) // defaults kept
+}
+
+func TestSetAddressErrors(t *testing.T) {
+	is := is.New(t)
+
+	for _, uri := range []string{
+		"http://monitor:6557",
+		"unix://host/path",
+		"unix://",
+		"unix:///live?ca=x",
+		"tcp://:6557",
+		"tcp://monitor:6557/path",
+		"tcp://monitor:6557?ca=x",
+		"tls://monitor?bogus=1",
+		"tls://monitor?insecure=maybe",
+	} {
+		_, err := NewLiveStatusConfigURI(uri)
+		is.True(err != nil) // uri should be rejected
+	}
+
+	// A rejected URI leaves the config as it was.
+	cfg := NewLiveStatusConfig("old:6557")
+	cfg.CAFile = "/old/ca.pem"
+	is.True(cfg.SetAddress("tls://new?ca=/new/ca.pem&servername=new&insecure=maybe") != nil)
+	is.Equal(cfg.Address, "old:6557")
+	is.Equal(cfg.CAFile, "/old/ca.pem")
+	is.Equal(cfg.ServerName, "")
+	is.True(!cfg.UseTLS)
+}
+
+func TestConnectURIAddresses(t *testing.T) {
+	is := is.New(t)
+
+	// A socket path with a colon, which the old heuristic took for host:port.
+	sock := filepath.Join(t.TempDir(), "site:prod.sock")
+	ln, err := net.Listen("unix", sock)
+	is.NoErr(err)
+	defer ln.Close()
+
+	for _, addr := range []string{"unix://" + sock, sock} {
+		go serveOnce(ln)
+		res, err := QueryOneOff(context.Background(), "GET status", NewLiveStatusConfig(addr))
+		is.NoErr(err)
+		is.Equal(string(res.Data), "ok") // response over addr
+	}
+
+	ln6, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("no IPv6 loopback: %v", err)
+	}
+	defer ln6.Close()
+	go serveOnce(ln6)
+	_, port, _ := net.SplitHostPort(ln6.Addr().String())
+	res, err := QueryOneOff(context.Background(), "GET status", NewLiveStatusConfig("tcp://[::1]:"+port))
+	is.NoErr(err)
+	is.Equal(string(res.Data), "ok")
+}
+
+func TestConnectInvalidURI(t *testing.T) {
+	is := is.New(t)
+
+	_, err := QueryOneOff(context.Background(), "GET status", NewLiveStatusConfig("ftp://monitor"))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "unsupported scheme"))
+}
+
+// serveOnce answers a single fixed16 request on the next accepted connection.
+func serveOnce(ln net.Listener) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil || line == "\n" {
+			break
+		}
+	}
+	fmt.Fprintf(conn, "%03d %11d\n%s", 200, 2, "ok")
+}
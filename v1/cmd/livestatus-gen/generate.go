@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+
+	livestatus "livestatus/v1"
+)
+
+// genColumn is one column as rendered into the generated package.
+type genColumn struct {
+	Ident       string // Go identifier, e.g. HostName
+	Name        string // Livestatus name, e.g. host_name
+	Kind        string // livestatus.XxxColumn type
+	GoType      string // Row field type
+	Description string
+}
+
+type genTable struct {
+	Package    string
+	Table      string
+	ImportPath string
+	Columns    []genColumn
+	NeedsTime  bool
+}
+
+// reserved identifiers are declared by the template itself.
+var reserved = map[string]bool{"Table": true, "Row": true, "AllColumns": true, "NewQuery": true}
+
+// initialisms are upper-cased as a whole when they form a word of a column name.
+var initialisms = map[string]string{
+	"id": "ID", "url": "URL", "ip": "IP", "ipv4": "IPv4", "ipv6": "IPv6", "api": "API",
+	"http": "HTTP", "json": "JSON", "uuid": "UUID", "dns": "DNS", "sla": "SLA",
+}
+
+// columnKinds maps catalog types to the typed column and Row field type.
+var columnKinds = map[livestatus.ColumnType][2]string{
+	livestatus.ColumnInt:    {"IntColumn", "int"},
+	livestatus.ColumnFloat:  {"FloatColumn", "float64"},
+	livestatus.ColumnString: {"StringColumn", "string"},
+	livestatus.ColumnTime:   {"TimeColumn", "time.Time"},
+	livestatus.ColumnList:   {"ListColumn", "[]string"},
+	livestatus.ColumnDict:   {"DictColumn", "map[string]string"},
+	livestatus.ColumnBlob:   {"BlobColumn", "string"},
+}
+
+// generateTable renders the Go source for one table.
+func generateTable(schema *livestatus.Schema, table livestatus.Table, importPath string) ([]byte, error) {
+	cols := schema.Columns(table)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %q not found in schema", table)
+	}
+	data := genTable{
+		Package:    packageName(string(table)),
+		Table:      string(table),
+		ImportPath: importPath,
+	}
+	used := map[string]bool{}
+	for _, c := range cols {
+		kind, ok := columnKinds[c.Type]
+		if !ok {
+			return nil, fmt.Errorf("column %s.%s: unsupported type %q", table, c.Name, c.Type)
+		}
+		ident := identifier(c.Name)
+		if reserved[ident] {
+			ident += "Column"
+		}
+		for base, i := ident, 2; used[ident]; i++ {
+			ident = fmt.Sprintf("%s%d", base, i)
+		}
+		used[ident] = true
+		if c.Type == livestatus.ColumnTime {
+			data.NeedsTime = true
+		}
+		data.Columns = append(data.Columns, genColumn{
+			Ident:       ident,
+			Name:        c.Name,
+			Kind:        kind[0],
+			GoType:      kind[1],
+			Description: strings.Join(strings.Fields(c.Description), " "),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := tableTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code for %q: %w", table, err)
+	}
+	return src, nil
+}
+
+// identifier turns a snake_case column name into an exported Go identifier.
+func identifier(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if up, ok := initialisms[strings.ToLower(word)]; ok {
+			sb.WriteString(up)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	ident := sb.String()
+	if ident == "" || unicode.IsDigit(rune(ident[0])) {
+		ident = "C" + ident
+	}
+	return ident
+}
+
+// packageName derives a Go package name from a table name.
+func packageName(table string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(table) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	name := sb.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "t" + name
+	}
+	return name
+}
+
+var tableTemplate = template.Must(template.New("table").Parse(`// Code generated by livestatus-gen; DO NOT EDIT.
+
+// Package {{.Package}} provides typed columns for the Livestatus "{{.Table}}" table.
+package {{.Package}}
+
+import (
+{{- if .NeedsTime}}
+	"time"
+{{end}}
+	livestatus "{{.ImportPath}}"
+)
+
+// Table is the Livestatus table name.
+const Table livestatus.Table = "{{.Table}}"
+
+// Columns of the {{.Table}} table.
+var (
+{{- range .Columns}}
+	{{- if .Description}}
+	// {{.Ident}}: {{.Description}}
+	{{- end}}
+	{{.Ident}} = livestatus.{{.Kind}}("{{.Name}}")
+{{- end}}
+)
+
+// AllColumns lists every column of the table in Row order.
+var AllColumns = []livestatus.Column{
+{{- range .Columns}}
+	{{.Ident}},
+{{- end}}
+}
+
+// Row holds one row of the {{.Table}} table; decode into it with livestatus.Unmarshal.
+type Row struct {
+{{- range .Columns}}
+	{{.Ident}} {{.GoType}} ` + "`" + `livestatus:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+
+// NewQuery starts a query on the table selecting cols, or every column if none are given.
+func NewQuery(cols ...livestatus.Column) *livestatus.LiveStatusQuery {
+	if len(cols) == 0 {
+		cols = AllColumns
+	}
+	return livestatus.NewLiveStatusQuery(Table, livestatus.ColumnNames(cols...)...)
+}
+`))
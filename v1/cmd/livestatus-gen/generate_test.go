@@ -0,0 +1,83 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	livestatus "livestatus/v1"
+)
+
+const testDump = `[
+["hosts","name","string","Host name"],
+["hosts","state","int","Current state"],
+["hosts","last_check","time","Time of the\nlast check"],
+["hosts","custom_variables","dict",""],
+["hosts","downtime_id","int","Downtime id"],
+["hosts","table","string","Clashes with the Table constant"],
+["services","host_name","string","Host name"],
+["services","state","int","Current state"],
+["status","program_version","string","Core version"]
+]`
+
+func TestIdentifier(t *testing.T) {
+	is := is.New(t)
+	is.Equal(identifier("host_name"), "HostName")
+	is.Equal(identifier("downtime_id"), "DowntimeID")
+	is.Equal(identifier("action_url_expanded"), "ActionURLExpanded")
+	is.Equal(identifier("4xx_count"), "C4xxCount")
+	is.Equal(packageName("host_groups"), "hostgroups")
+	is.Equal(packageName("3d"), "t3d")
+}
+
+func TestGenerateTable(t *testing.T) {
+	is := is.New(t)
+	s, err := livestatus.ParseSchema([]byte(testDump))
+	is.NoErr(err)
+
+	src, err := generateTable(s, "hosts", "livestatus/v1")
+	is.NoErr(err)
+	f, err := parser.ParseFile(token.NewFileSet(), "hosts.go", src, parser.ParseComments)
+	is.NoErr(err)
+	is.Equal(f.Name.Name, "hosts")
+
+	code := string(src)
+	is.True(strings.Contains(code, `const Table livestatus.Table = "hosts"`))
+	is.True(strings.Contains(code, `State = livestatus.IntColumn("state")`))
+	is.True(strings.Contains(code, `LastCheck = livestatus.TimeColumn("last_check")`))
+	is.True(strings.Contains(code, "// LastCheck: Time of the last check"))
+	is.True(strings.Contains(code, `TableColumn = livestatus.StringColumn("table")`))
+	is.True(strings.Contains(code, "LastCheck       time.Time"))
+	is.True(strings.Contains(code, `"time"`))
+
+	// No time column, no time import.
+	src, err = generateTable(s, "services", "livestatus/v1")
+	is.NoErr(err)
+	is.True(!strings.Contains(string(src), `"time"`))
+
+	_, err = generateTable(s, "nope", "livestatus/v1")
+	is.True(err != nil)
+}
+
+func TestRun(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "columns.json")
+	is.NoErr(os.WriteFile(dump, []byte(testDump), 0o644))
+
+	out := filepath.Join(dir, "gen")
+	is.NoErr(run([]string{"-dump", dump, "-out", out, "-tables", "hosts, services"}))
+	_, err := os.Stat(filepath.Join(out, "hosts", "hosts.go"))
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(out, "services", "services.go"))
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(out, "status"))
+	is.True(os.IsNotExist(err))
+
+	is.True(run([]string{"-out", out}) != nil) // needs -site or -dump
+}
@@ -0,0 +1,96 @@
+// Command livestatus-gen generates typed column constants, filter helpers and
+// row structs for Livestatus tables from the "GET columns" catalog.
+//
+// For every selected table it writes <out>/<table>/<table>.go containing a
+// package named after the table, e.g.
+//
+//	services.NewQuery(services.HostName, services.State).
+//		FilterExpr(services.State.Eq(2))
+//
+// The catalog is read from a live site (-site) or from a saved JSON response to
+// livestatus.SchemaQuery (-dump), which allows running offline:
+//
+//	//go:generate go run livestatus/v1/cmd/livestatus-gen -dump columns.json -out ./lql -tables hosts,services
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	livestatus "livestatus/v1"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "livestatus-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("livestatus-gen", flag.ContinueOnError)
+	site := fs.String("site", "", "livestatus address to load the column catalog from")
+	dump := fs.String("dump", "", "saved JSON response to livestatus.SchemaQuery (offline mode)")
+	out := fs.String("out", ".", "output directory; one sub-package per table is created")
+	tables := fs.String("tables", "", "comma-separated tables to generate (default: all)")
+	importPath := fs.String("import", "livestatus/v1", "import path of the livestatus package")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for loading the catalog from -site")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schema, err := loadSchema(*site, *dump, *timeout)
+	if err != nil {
+		return err
+	}
+
+	selected := schema.Tables()
+	if *tables != "" {
+		selected = nil
+		for _, t := range strings.Split(*tables, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				selected = append(selected, livestatus.Table(t))
+			}
+		}
+	}
+
+	for _, table := range selected {
+		src, err := generateTable(schema, table, *importPath)
+		if err != nil {
+			return err
+		}
+		pkg := packageName(string(table))
+		dir := filepath.Join(*out, pkg)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, pkg+".go"), src, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadSchema(site, dump string, timeout time.Duration) (*livestatus.Schema, error) {
+	switch {
+	case site != "" && dump != "":
+		return nil, fmt.Errorf("use either -site or -dump, not both")
+	case dump != "":
+		data, err := os.ReadFile(dump)
+		if err != nil {
+			return nil, err
+		}
+		return livestatus.ParseSchema(data)
+	case site != "":
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return livestatus.LoadSchema(ctx, livestatus.NewLiveStatusConfig(site))
+	default:
+		return nil, fmt.Errorf("one of -site or -dump is required")
+	}
+}
@@ -0,0 +1,102 @@
+package livestatus
+
+import (
+	"strconv"
+	"time"
+)
+
+// Column is a typed reference to a Livestatus column. The typed column kinds
+// below are what livestatus-gen emits per table (e.g. services.State is an
+// IntColumn), so filters can be written as services.State.Eq(2) and checked by
+// the compiler instead of as free-form strings.
+type Column interface {
+	Name() string
+}
+
+// ColumnNames converts typed columns into the names NewLiveStatusQuery expects.
+func ColumnNames(cols ...Column) []string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = c.Name()
+	}
+	return out
+}
+
+// IntColumn references a column of type int.
+type IntColumn string
+
+func (c IntColumn) Name() string    { return string(c) }
+func (c IntColumn) Eq(v int64) Expr { return Cond(string(c), OpEq, strconv.FormatInt(v, 10)) }
+func (c IntColumn) Ne(v int64) Expr { return Cond(string(c), OpNe, strconv.FormatInt(v, 10)) }
+func (c IntColumn) Lt(v int64) Expr { return Cond(string(c), OpLt, strconv.FormatInt(v, 10)) }
+func (c IntColumn) Le(v int64) Expr { return Cond(string(c), OpLe, strconv.FormatInt(v, 10)) }
+func (c IntColumn) Gt(v int64) Expr { return Cond(string(c), OpGt, strconv.FormatInt(v, 10)) }
+func (c IntColumn) Ge(v int64) Expr { return Cond(string(c), OpGe, strconv.FormatInt(v, 10)) }
+
+// Is matches a 0/1 flag column (e.g. acknowledged) against on.
+func (c IntColumn) Is(on bool) Expr {
+	if on {
+		return c.Eq(1)
+	}
+	return c.Eq(0)
+}
+
+// FloatColumn references a column of type float.
+type FloatColumn string
+
+func (c FloatColumn) Name() string      { return string(c) }
+func (c FloatColumn) Eq(v float64) Expr { return Cond(string(c), OpEq, formatFloat(v)) }
+func (c FloatColumn) Ne(v float64) Expr { return Cond(string(c), OpNe, formatFloat(v)) }
+func (c FloatColumn) Lt(v float64) Expr { return Cond(string(c), OpLt, formatFloat(v)) }
+func (c FloatColumn) Le(v float64) Expr { return Cond(string(c), OpLe, formatFloat(v)) }
+func (c FloatColumn) Gt(v float64) Expr { return Cond(string(c), OpGt, formatFloat(v)) }
+func (c FloatColumn) Ge(v float64) Expr { return Cond(string(c), OpGe, formatFloat(v)) }
+
+// StringColumn references a column of type string.
+type StringColumn string
+
+func (c StringColumn) Name() string              { return string(c) }
+func (c StringColumn) Eq(v string) Expr          { return Cond(string(c), OpEq, v) }
+func (c StringColumn) Ne(v string) Expr          { return Cond(string(c), OpNe, v) }
+func (c StringColumn) EqI(v string) Expr         { return Cond(string(c), OpEqIC, v) }
+func (c StringColumn) Re(pattern string) Expr    { return Cond(string(c), OpRe, pattern) }
+func (c StringColumn) ReI(pattern string) Expr   { return Cond(string(c), OpReIC, pattern) }
+func (c StringColumn) NotRe(pattern string) Expr { return Cond(string(c), OpNRe, pattern) }
+
+// TimeColumn references a column of type time (unix seconds).
+type TimeColumn string
+
+func (c TimeColumn) Name() string            { return string(c) }
+func (c TimeColumn) Eq(t time.Time) Expr     { return Cond(string(c), OpEq, formatUnix(t)) }
+func (c TimeColumn) Ne(t time.Time) Expr     { return Cond(string(c), OpNe, formatUnix(t)) }
+func (c TimeColumn) Before(t time.Time) Expr { return Cond(string(c), OpLt, formatUnix(t)) }
+func (c TimeColumn) After(t time.Time) Expr  { return Cond(string(c), OpGt, formatUnix(t)) }
+func (c TimeColumn) Since(t time.Time) Expr  { return Cond(string(c), OpGe, formatUnix(t)) }
+
+// ListColumn references a column of type list.
+type ListColumn string
+
+func (c ListColumn) Name() string              { return string(c) }
+func (c ListColumn) Contains(v string) Expr    { return Cond(string(c), OpGe, v) }
+func (c ListColumn) NotContains(v string) Expr { return Cond(string(c), OpLt, v) }
+func (c ListColumn) ContainsI(v string) Expr   { return Cond(string(c), OpLe, v) }
+func (c ListColumn) Empty() Expr               { return Cond(string(c), OpEq, "") }
+func (c ListColumn) NotEmpty() Expr            { return Cond(string(c), OpNe, "") }
+
+// DictColumn references a column of type dict (e.g. custom_variables).
+type DictColumn string
+
+func (c DictColumn) Name() string                 { return string(c) }
+func (c DictColumn) Eq(key, value string) Expr    { return Cond(string(c), OpEq, key+" "+value) }
+func (c DictColumn) Ne(key, value string) Expr    { return Cond(string(c), OpNe, key+" "+value) }
+func (c DictColumn) Re(key, pattern string) Expr  { return Cond(string(c), OpRe, key+" "+pattern) }
+func (c DictColumn) ReI(key, pattern string) Expr { return Cond(string(c), OpReIC, key+" "+pattern) }
+
+// BlobColumn references a column of type blob; blobs cannot be filtered.
+type BlobColumn string
+
+func (c BlobColumn) Name() string { return string(c) }
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func formatUnix(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) }
@@ -0,0 +1,47 @@
+package livestatus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestTypedColumns(t *testing.T) {
+	is := is.New(t)
+
+	state := IntColumn("state")
+	latency := FloatColumn("latency")
+	name := StringColumn("host_name")
+	lastCheck := TimeColumn("last_check")
+	groups := ListColumn("groups")
+	vars := DictColumn("custom_variables")
+
+	is.Equal(ExprString(state.Eq(2)), "Filter: state = 2")
+	is.Equal(ExprString(state.Is(false)), "Filter: state = 0")
+	is.Equal(ExprString(latency.Gt(1.5)), "Filter: latency > 1.5")
+	is.Equal(ExprString(name.ReI("^web")), "Filter: host_name ~~ ^web")
+	is.Equal(ExprString(name.EqI("Web01")), "Filter: host_name =~ Web01")
+	is.Equal(ExprString(lastCheck.Since(time.Unix(1700000000, 0))), "Filter: last_check >= 1700000000")
+	is.Equal(ExprString(groups.Contains("linux")), "Filter: groups >= linux")
+	is.Equal(ExprString(groups.Empty()), "Filter: groups = ")
+	is.Equal(ExprString(vars.Eq("ENV", "prod")), "Filter: custom_variables = ENV prod")
+
+	is.Equal(ColumnNames(name, state, BlobColumn("file")), []string{"host_name", "state", "file"})
+}
+
+func TestTypedColumnsValidate(t *testing.T) {
+	is := is.New(t)
+	s := testSchema(t)
+
+	// Every helper produces conditions the schema accepts for its column type.
+	q := NewLiveStatusQuery(Table("hosts"), ColumnNames(StringColumn("name"), IntColumn("state"))...).
+		FilterExpr(And(
+			IntColumn("state").Ne(0),
+			FloatColumn("latency").Le(2),
+			TimeColumn("last_check").Before(time.Unix(1700000000, 0)),
+			ListColumn("parents").NotContains("router"),
+			DictColumn("custom_variables").Re("ENV", "^prod"),
+		))
+	is.NoErr(s.Validate(q))
+}
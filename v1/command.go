@@ -0,0 +1,248 @@
+package livestatus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is a single external command, sent as
+//
+//	COMMAND [<unix time>] NAME;arg1;arg2;...
+//
+// Livestatus sends no response to commands: a command is accepted once it has
+// been written to the socket, and errors only show up in the core's log.
+//
+// Arguments are split on semicolons by the core, so they cannot be quoted.
+// Build strips CR/LF and NUL from every argument and replaces semicolons in
+// positional arguments with spaces. The free-text argument that ends most
+// commands (comment, plugin output) is read up to the end of the line, so
+// semicolons are kept there.
+type Command struct {
+	name     string
+	args     []string
+	freeText bool // the last argument runs to the end of the line
+	at       time.Time
+}
+
+// NewCommand creates a command with positional arguments. Use it for commands
+// without a typed constructor below.
+func NewCommand(name string, args ...string) *Command {
+	return &Command{name: safeToken(name), args: append([]string(nil), args...)}
+}
+
+// newTextCommand creates a command whose last argument is free text.
+func newTextCommand(name string, args ...string) *Command {
+	c := NewCommand(name, args...)
+	c.freeText = true
+	return c
+}
+
+// At sets the command timestamp. By default Build uses the current time.
+func (c *Command) At(t time.Time) *Command {
+	c.at = t
+	return c
+}
+
+// Name returns the command name, e.g. ACKNOWLEDGE_HOST_PROBLEM.
+func (c *Command) Name() string { return c.name }
+
+// Args returns a copy of the raw (unescaped) arguments.
+func (c *Command) Args() []string { return append([]string(nil), c.args...) }
+
+// Build renders the command line, terminated by a blank line like any other request.
+func (c *Command) Build() string {
+	at := c.at
+	if at.IsZero() {
+		at = time.Now()
+	}
+	var sb strings.Builder
+	sb.WriteString("COMMAND [")
+	sb.WriteString(strconv.FormatInt(at.Unix(), 10))
+	sb.WriteString("] ")
+	sb.WriteString(c.name)
+	for i, arg := range c.args {
+		arg = safeValue(arg)
+		if !c.freeText || i < len(c.args)-1 {
+			arg = strings.ReplaceAll(arg, ";", " ")
+		}
+		sb.WriteByte(';')
+		sb.WriteString(arg)
+	}
+	sb.WriteString("\n\n")
+	return sb.String()
+}
+
+// isCommandRequest reports whether a raw request is a COMMAND rather than a GET.
+func isCommandRequest(request string) bool {
+	return strings.HasPrefix(strings.TrimLeft(request, " \t\r\n"), "COMMAND ")
+}
+
+// ---------- Typed constructors for common Nagios/Checkmk commands ----------
+
+// AckOptions controls how a problem is acknowledged.
+type AckOptions struct {
+	Sticky     bool // keep the acknowledgement until the object is OK again, not just until the next state change
+	Notify     bool // send an acknowledgement notification to contacts
+	Persistent bool // keep the comment after the acknowledgement is removed
+	Author     string
+	Comment    string
+}
+
+func (o AckOptions) args() []string {
+	sticky := "1"
+	if o.Sticky {
+		sticky = "2"
+	}
+	return []string{sticky, flag(o.Notify), flag(o.Persistent), o.Author, o.Comment}
+}
+
+// AcknowledgeHostProblem acknowledges the current problem of a host.
+func AcknowledgeHostProblem(host string, opts AckOptions) *Command {
+	return newTextCommand("ACKNOWLEDGE_HOST_PROBLEM", append([]string{host}, opts.args()...)...)
+}
+
+// AcknowledgeServiceProblem acknowledges the current problem of a service.
+func AcknowledgeServiceProblem(host, service string, opts AckOptions) *Command {
+	return newTextCommand("ACKNOWLEDGE_SVC_PROBLEM", append([]string{host, service}, opts.args()...)...)
+}
+
+// RemoveHostAcknowledgement removes the acknowledgement of a host problem.
+func RemoveHostAcknowledgement(host string) *Command {
+	return NewCommand("REMOVE_HOST_ACKNOWLEDGEMENT", host)
+}
+
+// RemoveServiceAcknowledgement removes the acknowledgement of a service problem.
+func RemoveServiceAcknowledgement(host, service string) *Command {
+	return NewCommand("REMOVE_SVC_ACKNOWLEDGEMENT", host, service)
+}
+
+// Downtime describes a scheduled downtime. A fixed downtime covers exactly
+// Start..End; a flexible one starts with the first problem in that window
+// and lasts Duration.
+type Downtime struct {
+	Start     time.Time
+	End       time.Time
+	Fixed     bool
+	TriggerID int64 // downtime that triggers this one, 0 for none
+	Duration  time.Duration
+	Author    string
+	Comment   string
+}
+
+func (d Downtime) args() []string {
+	return []string{
+		strconv.FormatInt(d.Start.Unix(), 10),
+		strconv.FormatInt(d.End.Unix(), 10),
+		flag(d.Fixed),
+		strconv.FormatInt(d.TriggerID, 10),
+		strconv.FormatInt(int64(d.Duration/time.Second), 10),
+		d.Author,
+		d.Comment,
+	}
+}
+
+// ScheduleHostDowntime schedules a downtime for a host.
+func ScheduleHostDowntime(host string, d Downtime) *Command {
+	return newTextCommand("SCHEDULE_HOST_DOWNTIME", append([]string{host}, d.args()...)...)
+}
+
+// ScheduleServiceDowntime schedules a downtime for a service.
+func ScheduleServiceDowntime(host, service string, d Downtime) *Command {
+	return newTextCommand("SCHEDULE_SVC_DOWNTIME", append([]string{host, service}, d.args()...)...)
+}
+
+// DeleteHostDowntime cancels a host downtime by its id.
+func DeleteHostDowntime(id int64) *Command {
+	return NewCommand("DEL_HOST_DOWNTIME", strconv.FormatInt(id, 10))
+}
+
+// DeleteServiceDowntime cancels a service downtime by its id.
+func DeleteServiceDowntime(id int64) *Command {
+	return NewCommand("DEL_SVC_DOWNTIME", strconv.FormatInt(id, 10))
+}
+
+// ScheduleHostCheck reschedules the active check of a host. A forced check runs
+// even outside the check period or when active checks are disabled.
+func ScheduleHostCheck(host string, at time.Time, forced bool) *Command {
+	name := "SCHEDULE_HOST_CHECK"
+	if forced {
+		name = "SCHEDULE_FORCED_HOST_CHECK"
+	}
+	return NewCommand(name, host, strconv.FormatInt(at.Unix(), 10))
+}
+
+// ScheduleServiceCheck reschedules the active check of a service.
+func ScheduleServiceCheck(host, service string, at time.Time, forced bool) *Command {
+	name := "SCHEDULE_SVC_CHECK"
+	if forced {
+		name = "SCHEDULE_FORCED_SVC_CHECK"
+	}
+	return NewCommand(name, host, service, strconv.FormatInt(at.Unix(), 10))
+}
+
+// AddHostComment adds a comment to a host. Persistent comments survive a core restart.
+func AddHostComment(host string, persistent bool, author, comment string) *Command {
+	return newTextCommand("ADD_HOST_COMMENT", host, flag(persistent), author, comment)
+}
+
+// AddServiceComment adds a comment to a service.
+func AddServiceComment(host, service string, persistent bool, author, comment string) *Command {
+	return newTextCommand("ADD_SVC_COMMENT", host, service, flag(persistent), author, comment)
+}
+
+// DeleteHostComment deletes a host comment by its id.
+func DeleteHostComment(id int64) *Command {
+	return NewCommand("DEL_HOST_COMMENT", strconv.FormatInt(id, 10))
+}
+
+// DeleteServiceComment deletes a service comment by its id.
+func DeleteServiceComment(id int64) *Command {
+	return NewCommand("DEL_SVC_COMMENT", strconv.FormatInt(id, 10))
+}
+
+// EnableHostNotifications enables notifications for a host.
+func EnableHostNotifications(host string) *Command {
+	return NewCommand("ENABLE_HOST_NOTIFICATIONS", host)
+}
+
+// DisableHostNotifications disables notifications for a host.
+func DisableHostNotifications(host string) *Command {
+	return NewCommand("DISABLE_HOST_NOTIFICATIONS", host)
+}
+
+// EnableServiceNotifications enables notifications for a service.
+func EnableServiceNotifications(host, service string) *Command {
+	return NewCommand("ENABLE_SVC_NOTIFICATIONS", host, service)
+}
+
+// DisableServiceNotifications disables notifications for a service.
+func DisableServiceNotifications(host, service string) *Command {
+	return NewCommand("DISABLE_SVC_NOTIFICATIONS", host, service)
+}
+
+// EnableNotifications enables notifications globally.
+func EnableNotifications() *Command { return NewCommand("ENABLE_NOTIFICATIONS") }
+
+// DisableNotifications disables notifications globally.
+func DisableNotifications() *Command { return NewCommand("DISABLE_NOTIFICATIONS") }
+
+// ProcessHostCheckResult submits a passive check result for a host
+// (0=UP, 1=DOWN, 2=UNREACHABLE).
+func ProcessHostCheckResult(host string, state int, output string) *Command {
+	return newTextCommand("PROCESS_HOST_CHECK_RESULT", host, strconv.Itoa(state), output)
+}
+
+// ProcessServiceCheckResult submits a passive check result for a service
+// (0=OK, 1=WARN, 2=CRIT, 3=UNKNOWN). Performance data follows a | in output.
+func ProcessServiceCheckResult(host, service string, state int, output string) *Command {
+	return newTextCommand("PROCESS_SERVICE_CHECK_RESULT", host, service, strconv.Itoa(state), output)
+}
+
+// flag renders a boolean command argument.
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,117 @@
+package livestatus
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCommandBuild(t *testing.T) {
+	is := is.New(t)
+	at := time.Unix(1700000000, 0)
+
+	cases := []struct {
+		cmd  *Command
+		want string
+	}{
+		{
+			AcknowledgeServiceProblem("web01", "HTTP", AckOptions{Sticky: true, Notify: true, Author: "ops", Comment: "on it; ticket 42"}),
+			"COMMAND [1700000000] ACKNOWLEDGE_SVC_PROBLEM;web01;HTTP;2;1;0;ops;on it; ticket 42",
+		},
+		{
+			ScheduleHostDowntime("web01", Downtime{Start: at, End: at.Add(2 * time.Hour), Fixed: true, Author: "ops", Comment: "patching"}),
+			"COMMAND [1700000000] SCHEDULE_HOST_DOWNTIME;web01;1700000000;1700007200;1;0;0;ops;patching",
+		},
+		{
+			ScheduleServiceCheck("web01", "CPU load", at, true),
+			"COMMAND [1700000000] SCHEDULE_FORCED_SVC_CHECK;web01;CPU load;1700000000",
+		},
+		{
+			ProcessServiceCheckResult("web01", "Backup", 2, "CRIT - failed|size=0"),
+			"COMMAND [1700000000] PROCESS_SERVICE_CHECK_RESULT;web01;Backup;2;CRIT - failed|size=0",
+		},
+		{DisableNotifications(), "COMMAND [1700000000] DISABLE_NOTIFICATIONS"},
+		{DeleteServiceDowntime(17), "COMMAND [1700000000] DEL_SVC_DOWNTIME;17"},
+	}
+	for _, c := range cases {
+		is.Equal(c.cmd.At(at).Build(), c.want+"\n\n")
+	}
+}
+
+func TestCommandEscaping(t *testing.T) {
+	is := is.New(t)
+	at := time.Unix(1700000000, 0)
+
+	// A newline must not smuggle in a second command; semicolons in positional
+	// arguments must not shift the remaining arguments.
+	cmd := AddHostComment("web;01", false, "ops\n", "line1\r\nCOMMAND [0] SHUTDOWN_PROGRAM").At(at)
+	got := cmd.Build()
+	is.Equal(got, "COMMAND [1700000000] ADD_HOST_COMMENT;web 01;0;ops ;line1  COMMAND [0] SHUTDOWN_PROGRAM\n\n")
+	is.Equal(strings.Count(got, "\n"), 2)
+
+	// Commands without free text treat every argument as positional.
+	is.Equal(NewCommand("CUSTOM", "a;b").At(at).Build(), "COMMAND [1700000000] CUSTOM;a b\n\n")
+	is.Equal(cmd.Name(), "ADD_HOST_COMMENT")
+	is.True(isCommandRequest(cmd.Build()))
+	is.True(!isCommandRequest(NewLiveStatusQuery(Table("hosts")).Build()))
+}
+
+func TestSendCommandOneOff(t *testing.T) {
+	is := is.New(t)
+	reqs := make(chan string, 1)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		reqs <- req
+		return StatusOK, ""
+	})
+	cfg := NewLiveStatusConfig(addr)
+	cfg.ReadTimeout = 5 * time.Second
+
+	start := time.Now()
+	is.NoErr(SendCommandOneOff(context.Background(), EnableHostNotifications("web01"), cfg))
+	is.True(time.Since(start) < 2*time.Second) // did not wait for a response
+
+	select {
+	case req := <-reqs:
+		is.True(strings.HasSuffix(req, "] ENABLE_HOST_NOTIFICATIONS;web01\n"))
+	case <-time.After(time.Second):
+		t.Fatal("command not received")
+	}
+}
+
+func TestActorSendCommand(t *testing.T) {
+	is := is.New(t)
+	addr, accepted := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, `[["web01"]]`
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 4)
+	actor := NewLiveStatusActor(logger, "test_command", NewLiveStatusConfig(addr), 4, results, prometheus.NewRegistry())
+	defer actor.Close()
+	actor.SetSchema(testSchema(t)) // commands are not validated against the schema
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id, err := actor.SendCommand(ctx, AcknowledgeHostProblem("web01", AckOptions{Comment: "ack"}))
+	is.NoErr(err)
+	msg := recvResult(t, results, 5*time.Second)
+	is.Equal(msg.ID, id)
+	is.NoErr(msg.Result.Error)
+	is.Equal(msg.Result.StatusCode, StatusOK)
+	is.Equal(len(msg.Result.Data), 0)
+
+	// No response was expected, so the connection is still in sync for a query.
+	id, err = actor.SendQuery(ctx, *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON))
+	is.NoErr(err)
+	msg = recvResult(t, results, 5*time.Second)
+	is.Equal(msg.ID, id)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+	is.Equal(accepted.Load(), int32(1))
+}
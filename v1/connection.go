@@ -3,6 +3,7 @@ package livestatus
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -55,6 +56,15 @@ func parseFixed16Header(hdr []byte) (code int, length int, err error) {
 
 // ensureConn ensures there is a live connection and reader, dialing if necessary.
 func ensureConn(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn) (net.Conn, *bufio.Reader, error) {
+	if pc, ok := conn.(processConn); ok {
+		select {
+		case <-pc.exited():
+			// The probe below can't tell: an expired deadline fails reads before EOF is seen.
+			_ = conn.Close()
+			conn = nil
+		default:
+		}
+	}
 	if conn != nil {
 		// Probe existing connection liveness. We set an immediate read deadline and
 		// attempt a non-consuming Peek on a temporary reader. If it returns EOF or
@@ -74,8 +84,8 @@ func ensureConn(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn) (net.
 			clearDeadlines(conn)
 			return conn, tmp, nil
 		}
-		// Clear deadlines before redialing
-		clearDeadlines(conn)
+		// Release the dead connection (for a CommandDialer, its process) before redialing
+		_ = conn.Close()
 	}
 	if cfg == nil {
 		return nil, nil, fmt.Errorf("no config for livestatus connection")
@@ -94,13 +104,26 @@ func ensureConn(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn) (net.
 	return c, bufio.NewReader(c), nil
 }
 
-// execOverPersistentConn sends a single query with required headers and reads the fixed16 response.
-func execOverPersistentConn(logger *slog.Logger, ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader, query LiveStatusQuery) (*Result, error) {
-	if conn == nil || reader == nil {
-		return nil, fmt.Errorf("nil connection/reader")
-	}
+// transportError marks a failure of the connection itself, as opposed to a
+// Livestatus status or a decoding error. A connection that produced one must not
+// be reused since the request/response framing may be out of sync.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// isTransportError reports whether err signals a broken connection.
+func isTransportError(err error) bool {
+	var te *transportError
+	return errors.As(err, &te)
+}
+
+// writeRequest sends a single query with the headers required for persistent fixed16 framing.
+func writeRequest(logger *slog.Logger, ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, query LiveStatusQuery) (string, error) {
 	// Build an effective query enforcing required headers without parsing strings.
-	q := query // work on a local copy
+	q := query.Clone() // the caller's headers must not see ours
 	q.ResponseHeaderFixed16().KeepAlive(true)
 	queryStr := q.Build()
 	// Ensure request ends with a blank line per livestatus protocol
@@ -111,75 +134,224 @@ func execOverPersistentConn(logger *slog.Logger, ctx context.Context, cfg *LiveS
 		queryStr += "\n"
 	}
 	logger.Debug("execOverPersistentConn", "query", queryStr)
+	return queryStr, writeWithDeadline(ctx, cfg, conn, queryStr)
+}
 
-	// Derive deadlines: prefer ctx.Deadline if earlier; otherwise use cfg timeouts
-	// Write deadline first (use WriteTimeout when provided)
+// writeWithDeadline writes a complete request under the configured write deadline.
+func writeWithDeadline(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, request string) error {
+	// Use WriteTimeout when provided, falling back to ReadTimeout
+	var timeout time.Duration
 	if cfg != nil && cfg.WriteTimeout > 0 {
-		_ = conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
-	} else if cfg != nil && cfg.ReadTimeout > 0 {
-		// Fallback to ReadTimeout if WriteTimeout is not set
-		_ = conn.SetWriteDeadline(time.Now().Add(cfg.ReadTimeout))
+		timeout = cfg.WriteTimeout
+	} else if cfg != nil {
+		timeout = cfg.ReadTimeout
 	}
-	if dl, ok := ctx.Deadline(); ok {
+	if dl := ioDeadline(ctx, timeout); !dl.IsZero() {
 		_ = conn.SetWriteDeadline(dl)
 	}
-	if err := writeAll(conn, queryStr); err != nil {
+	if err := writeAll(conn, request); err != nil {
 		clearDeadlines(conn)
-		return nil, fmt.Errorf("write failed: %w", err)
+		return &transportError{fmt.Errorf("write failed: %w", err)}
 	}
-	// Then read deadline
-	if cfg != nil && cfg.ReadTimeout > 0 {
-		_ = conn.SetReadDeadline(time.Now().Add(cfg.ReadTimeout))
+	return nil
+}
+
+// execCommandOverPersistentConn writes an external command. Livestatus sends no
+// response to commands, so nothing is read and the connection stays in sync.
+func execCommandOverPersistentConn(logger *slog.Logger, ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, cmd *Command) error {
+	if conn == nil {
+		return fmt.Errorf("nil connection")
 	}
-	if dl, ok := ctx.Deadline(); ok {
+	request := cmd.Build()
+	logger.Debug("execCommandOverPersistentConn", "command", request)
+	defer clearDeadlines(conn)
+	return writeWithDeadline(ctx, cfg, conn, request)
+}
+
+// readFixed16Header arms the read deadline and reads the 16-byte response header.
+// The caller is responsible for clearing deadlines once the body has been read.
+func readFixed16Header(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader) (code int, n int, err error) {
+	var timeout time.Duration
+	if cfg != nil {
+		timeout = cfg.ReadTimeout
+	}
+	if dl := ioDeadline(ctx, timeout); !dl.IsZero() {
 		_ = conn.SetReadDeadline(dl)
 	}
-	defer clearDeadlines(conn)
 
 	// Allow early cancel between phases
 	if err := ctx.Err(); err != nil {
-		return nil, err
+		return 0, 0, &transportError{err}
 	}
 
-	// Read fixed16 header
 	var hdr [16]byte
 	if _, err := ioReadFull(reader, hdr[:]); err != nil {
-		logger.Warn("execOverPersistentConn", "err", err, "query", queryStr, "hdr", fmt.Sprintf("%x", hdr))
-		return nil, fmt.Errorf("failed reading fixed16 header: %w", err)
+		return 0, 0, &transportError{fmt.Errorf("failed reading fixed16 header: %w", err)}
 	}
-	code, n, err := parseFixed16Header(hdr[:])
+	code, n, err = parseFixed16Header(hdr[:])
 	if err != nil {
-		return nil, fmt.Errorf("invalid fixed16 header: %w", err)
+		return 0, 0, &transportError{fmt.Errorf("invalid fixed16 header: %w", err)}
 	}
+	return code, n, nil
+}
 
-	// Guard allocations based on configured cap (default 32 MiB)
-	maxBody := 32 << 20
+// statusError builds the error for a non-200 fixed16 response from its body.
+func statusError(code int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		msg = "livestatus error"
+	}
+	return fmt.Errorf("livestatus status %d: %s", code, msg)
+}
+
+// maxBodyBytes returns the configured response size cap (default 32 MiB).
+func maxBodyBytes(cfg *LiveStatusConfig) int {
 	if cfg != nil && cfg.MaxBodyBytes > 0 {
-		maxBody = int(cfg.MaxBodyBytes)
+		return int(cfg.MaxBodyBytes)
 	}
+	return 32 << 20
+}
+
+// readBody reads a fixed16 body of n bytes, enforcing the configured size cap.
+func readBody(cfg *LiveStatusConfig, reader *bufio.Reader, n int) ([]byte, error) {
+	// Guard allocations based on configured cap (default 32 MiB)
+	maxBody := maxBodyBytes(cfg)
 	if n < 0 || n > maxBody {
 		// Best-effort drain to keep connection reusable
 		if err := discardN(reader, int64(n)); err != nil {
-			return nil, fmt.Errorf("response too large (%d bytes) and drain failed: %v", n, err)
+			return nil, &transportError{fmt.Errorf("response too large (%d bytes) and drain failed: %v", n, err)}
 		}
 		return nil, fmt.Errorf("response too large: %d bytes (cap %d)", n, maxBody)
 	}
 
 	body := make([]byte, n)
 	if _, err := ioReadFull(reader, body); err != nil {
-		return nil, fmt.Errorf("failed reading body: %w", err)
+		return nil, &transportError{fmt.Errorf("failed reading body: %w", err)}
+	}
+	return body, nil
+}
+
+// execOverPersistentConn sends a single query with required headers and reads the fixed16 response.
+func execOverPersistentConn(logger *slog.Logger, ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader, query LiveStatusQuery) (*Result, error) {
+	if conn == nil || reader == nil {
+		return nil, fmt.Errorf("nil connection/reader")
+	}
+	queryStr, err := writeRequest(logger, ctx, cfg, conn, query)
+	if err != nil {
+		return nil, err
+	}
+	defer clearDeadlines(conn)
+	res, err := readResponseFixed16(ctx, cfg, conn, reader)
+	if err != nil {
+		logger.Warn("execOverPersistentConn", "err", err, "query", queryStr)
+	}
+	return res, err
+}
+
+// readResponseFixed16 reads one complete fixed16 response. Non-200 statuses are
+// reported in the Result; the error is reserved for transport and size failures.
+// Only the read deadline is touched, so a pipelined writer may use the
+// connection concurrently.
+func readResponseFixed16(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader) (*Result, error) {
+	defer conn.SetReadDeadline(time.Time{})
+	code, n, err := readFixed16Header(ctx, cfg, conn, reader)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(cfg, reader, n)
+	if err != nil {
+		return nil, err
 	}
 
 	if code != StatusOK {
-		msg := strings.TrimSpace(string(body))
-		if msg == "" {
-			msg = "livestatus error"
-		}
-		return &Result{StatusCode: code, Error: fmt.Errorf("livestatus status %d: %s", code, msg)}, nil
+		return &Result{StatusCode: code, Error: statusError(code, body)}, nil
 	}
 	return &Result{StatusCode: code, Data: body}, nil
 }
 
+// execStreamOverPersistentConn sends a query and decodes the fixed16 body row by
+// row as it arrives, handing each row to yield. Memory use is bounded by the
+// largest single row rather than the response size. If yield returns false the
+// rest of the body is drained so the connection stays usable.
+func execStreamOverPersistentConn(logger *slog.Logger, ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader, query LiveStatusQuery, yield func(Row) bool) (int, error) {
+	if conn == nil || reader == nil {
+		return 0, fmt.Errorf("nil connection/reader")
+	}
+	queryStr, err := writeRequest(logger, ctx, cfg, conn, query)
+	if err != nil {
+		return 0, err
+	}
+	defer clearDeadlines(conn)
+
+	code, n, err := readFixed16Header(ctx, cfg, conn, reader)
+	if err != nil {
+		logger.Warn("execStreamOverPersistentConn", "err", err, "query", queryStr)
+		return 0, err
+	}
+	if code != StatusOK {
+		body, err := readBody(cfg, reader, n)
+		if err != nil {
+			return code, err
+		}
+		return code, statusError(code, body)
+	}
+
+	// The read timeout bounds each read rather than the whole body, which may
+	// take a while to arrive and to be consumed.
+	var timeout time.Duration
+	if cfg != nil {
+		timeout = cfg.ReadTimeout
+	}
+	body := &io.LimitedReader{R: &deadlineReader{ctx: ctx, conn: conn, r: reader, timeout: timeout}, N: int64(n)}
+	decodeErr := streamRows(&query, body, yield)
+	// Whatever happened while decoding, consume the remainder of this response
+	// so the next request on the connection starts at a frame boundary.
+	if err := discardN(body, body.N); err != nil {
+		return code, &transportError{fmt.Errorf("failed draining body: %w", err)}
+	}
+	return code, decodeErr
+}
+
+// deadlineReader re-arms conn's read deadline before every read from r.
+type deadlineReader struct {
+	ctx     context.Context
+	conn    net.Conn
+	r       io.Reader
+	timeout time.Duration
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	if dl := ioDeadline(d.ctx, d.timeout); !dl.IsZero() {
+		_ = d.conn.SetReadDeadline(dl)
+		// Don't undo the immediate deadline of an interrupt that came first.
+		if d.ctx.Err() != nil {
+			_ = d.conn.SetReadDeadline(time.Now())
+		}
+	}
+	return d.r.Read(p)
+}
+
+// streamRows decodes rows of a response to q from r and passes them to yield
+// until it returns false.
+func streamRows(q *LiveStatusQuery, r io.Reader, yield func(Row) bool) error {
+	rr, err := newRowReader(q.outputFormat, r, q.separators())
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := rr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !yield(row) {
+			return nil
+		}
+	}
+}
+
 // writeAll handles short writes by looping until all bytes are written.
 func writeAll(w net.Conn, s string) error {
 	b := []byte(s)
@@ -193,6 +365,19 @@ func writeAll(w net.Conn, s string) error {
 	return nil
 }
 
+// ioDeadline returns the socket deadline for a read or write allowed to take
+// timeout: ctx's deadline if that is earlier. The zero time means none.
+func ioDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	var dl time.Time
+	if timeout > 0 {
+		dl = time.Now().Add(timeout)
+	}
+	if cdl, ok := ctx.Deadline(); ok && (dl.IsZero() || cdl.Before(dl)) {
+		dl = cdl
+	}
+	return dl
+}
+
 func clearDeadlines(conn net.Conn) {
 	_ = conn.SetDeadline(time.Time{})
 	_ = conn.SetReadDeadline(time.Time{})
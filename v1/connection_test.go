@@ -1,6 +1,14 @@
 package livestatus
 
-import "testing"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
 
 func TestParseFixed16Header_OK(t *testing.T) {
 	h := []byte("200 00000000012\n")
@@ -63,3 +71,59 @@ func TestParseFixed16Header_WeirdSeparatorsButParsable(t *testing.T) {
 		t.Fatalf("got code=%d len=%d; want 200 and 12", code, n)
 	}
 }
+
+// startFakeLivestatus serves responses on a local TCP port. handle is called
+// once per request with the raw request text. Like a real site it only sends a
+// fixed16 header when asked to, closes the connection after the response
+// unless "KeepAlive: on" was sent, and never answers COMMAND requests. It returns the listen address and a
+// counter of accepted connections.
+func startFakeLivestatus(t *testing.T, handle func(req string) (code int, body string)) (string, *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var req strings.Builder
+					for {
+						line, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						if line == "\n" {
+							break
+						}
+						req.WriteString(line)
+					}
+					text := req.String()
+					code, body := handle(text)
+					if strings.HasPrefix(text, "COMMAND ") {
+						continue // commands get no response
+					}
+					var err error
+					if strings.Contains(text, "ResponseHeader: fixed16\n") {
+						_, err = fmt.Fprintf(conn, "%03d %11d\n%s", code, len(body), body)
+					} else {
+						_, err = io.WriteString(conn, body)
+					}
+					if err != nil || !strings.Contains(text, "KeepAlive: on\n") {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), &accepted
+}
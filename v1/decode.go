@@ -0,0 +1,469 @@
+package livestatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Decoder maps response rows onto structs whose fields are tagged with
+// `livestatus:"column_name"`. Untagged exported fields match a column whose name
+// equals the field name case-insensitively; `livestatus:"-"` skips a field.
+//
+// Supported field types: string, signed/unsigned integers, floats, bool (0/1),
+// time.Time (unix seconds; 0 is the zero time), slices for list columns,
+// map[string]T for dict columns, structs for tuples (see ServiceWithState),
+// pointers to any of these, and any (raw cell).
+//
+// CSV cells are split using the separators of the query (see
+// LiveStatusQuery.Separators): list columns on the list separator and the
+// tuples inside them on the host/service separator. With the defaults a comma
+// in a value corrupts the list; pick separators that cannot occur in the data
+// (e.g. 31 and 30) when decoding free text such as plugin output.
+type Decoder struct {
+	format  OutputFormat
+	columns []string
+	headers bool // first row carries the column names
+	seps    separators
+}
+
+// NewDecoder returns a decoder for responses to q. Column order comes from q's
+// Columns (followed by its Stats aggregate names), or from the header row when
+// ColumnHeaders is on or no Columns were requested.
+func NewDecoder(q *LiveStatusQuery) *Decoder {
+	d := &Decoder{seps: defaultSeparators}
+	if q == nil {
+		d.headers = true
+		return d
+	}
+	d.format = q.outputFormat
+	d.seps = q.separators()
+	d.columns = append([]string(nil), q.columns...)
+	for _, a := range q.statsAggs {
+		d.columns = append(d.columns, a.Name)
+	}
+	if q.columnHdrs != nil {
+		d.headers = *q.columnHdrs
+	} else {
+		// Livestatus sends a header row by default when no Columns: are given.
+		d.headers = len(q.columns) == 0
+	}
+	return d
+}
+
+// Columns returns the column order currently used to map cells to fields.
+func (d *Decoder) Columns() []string {
+	return append([]string(nil), d.columns...)
+}
+
+// SetHeader takes the column order from a header row. Decode calls it for the
+// first row automatically; streaming callers use it for the first row they get.
+func (d *Decoder) SetHeader(row Row) {
+	d.columns = make([]string, len(row))
+	for i, c := range row {
+		d.columns[i] = cellString(c)
+	}
+}
+
+// Decode decodes a full response body into v, which must be a pointer to a
+// slice of structs or of struct pointers.
+func (d *Decoder) Decode(data []byte, v any) error {
+	if err := checkSliceTarget(v); err != nil {
+		return err
+	}
+	rows, err := parseRows(d.format, data, d.seps)
+	if err != nil {
+		return err
+	}
+	if d.headers && len(rows) > 0 {
+		d.SetHeader(rows[0])
+		rows = rows[1:]
+	}
+	return d.decodeRows(rows, v)
+}
+
+// checkSliceTarget reports whether v can be decoded into by decodeRows.
+func checkSliceTarget(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("livestatus: Decode needs a non-nil pointer to a slice, got %T", v)
+	}
+	elemType := rv.Elem().Type().Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("livestatus: Decode needs a slice of structs, got %T", v)
+	}
+	return nil
+}
+
+// decodeRows decodes header-less rows into v, which checkSliceTarget accepts.
+func (d *Decoder) decodeRows(rows []Row, v any) error {
+	slice := reflect.ValueOf(v).Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	out := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for i, row := range rows {
+		elem := reflect.New(structType)
+		if err := d.decodeRow(row, elem.Elem()); err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+		if isPtr {
+			out = reflect.Append(out, elem)
+		} else {
+			out = reflect.Append(out, elem.Elem())
+		}
+	}
+	slice.Set(out)
+	return nil
+}
+
+// DecodeRow decodes a single row into v, which must be a pointer to a struct.
+func (d *Decoder) DecodeRow(row Row, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("livestatus: DecodeRow needs a non-nil pointer to a struct, got %T", v)
+	}
+	return d.decodeRow(row, rv.Elem())
+}
+
+func (d *Decoder) decodeRow(row Row, dst reflect.Value) error {
+	fields := structFields(dst.Type())
+	for i, cell := range row {
+		if i >= len(d.columns) {
+			break
+		}
+		idx, ok := fields[strings.ToLower(d.columns[i])]
+		if !ok {
+			continue
+		}
+		if err := d.setValue(dst.Field(idx), cell, 0); err != nil {
+			return fmt.Errorf("column %q: %w", d.columns[i], err)
+		}
+	}
+	return nil
+}
+
+// Unmarshal decodes a response body produced by q into v (see Decoder.Decode).
+func Unmarshal(q *LiveStatusQuery, data []byte, v any) error {
+	return NewDecoder(q).Decode(data, v)
+}
+
+// Unmarshal decodes the result body into v, returning r.Error if the query failed.
+func (r *Result) Unmarshal(q *LiveStatusQuery, v any) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	return Unmarshal(q, r.Data, v)
+}
+
+// ---------- field mapping ----------
+
+var fieldCache sync.Map // reflect.Type -> map[string]int (lower-cased column -> field index)
+
+func structFields(t reflect.Type) map[string]int {
+	if m, ok := fieldCache.Load(t); ok {
+		return m.(map[string]int)
+	}
+	m := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("livestatus"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		m[strings.ToLower(name)] = i
+	}
+	fieldCache.Store(t, m)
+	return m
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setValue converts a cell into dst. depth tracks list nesting so CSV cells are
+// split on the list separator first and on the host/service separator below that.
+func (d *Decoder) setValue(dst reflect.Value, cell any, depth int) error {
+	if dst.Kind() == reflect.Pointer {
+		if cell == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		p := reflect.New(dst.Type().Elem())
+		if err := d.setValue(p.Elem(), cell, depth); err != nil {
+			return err
+		}
+		dst.Set(p)
+		return nil
+	}
+	if dst.Type() == timeType {
+		f, err := cellFloat(cell)
+		if err != nil {
+			return err
+		}
+		if f == 0 {
+			dst.Set(reflect.ValueOf(time.Time{}))
+		} else {
+			dst.Set(reflect.ValueOf(time.Unix(int64(f), 0)))
+		}
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		return d.setTuple(dst, cell, depth)
+	case reflect.Interface:
+		if cell != nil {
+			dst.Set(reflect.ValueOf(cell))
+		}
+	case reflect.String:
+		dst.SetString(cellString(cell))
+	case reflect.Bool:
+		b, err := cellBool(cell)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := cellInt(cell)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := cellInt(cell)
+		if err != nil {
+			return err
+		}
+		if n < 0 || dst.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := cellFloat(cell)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		items, err := d.cellList(cell, depth)
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, it := range items {
+			if err := d.setValue(s.Index(i), it, depth+1); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if dst.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", dst.Type().Key())
+		}
+		entries, err := d.cellDict(cell)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), len(entries))
+		for k, v := range entries {
+			ev := reflect.New(dst.Type().Elem()).Elem()
+			if err := d.setValue(ev, v, depth+1); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), ev)
+		}
+		dst.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", dst.Type())
+	}
+	return nil
+}
+
+// setTuple fills the exported fields of a struct, in declaration order, from a
+// tuple such as ["HTTP", 2, 1] or, in CSV, "HTTP|2|1". The last field takes the
+// rest of a CSV tuple, so a trailing plugin output may contain the separator.
+// Extra elements (newer Livestatus versions append some) are ignored.
+func (d *Decoder) setTuple(dst reflect.Value, cell any, depth int) error {
+	fields := tupleFields(dst.Type())
+	var items []any
+	switch t := cell.(type) {
+	case nil:
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	case []any:
+		items = t
+	case string:
+		for _, p := range strings.SplitN(t, string(d.seps.hostService), len(fields)) {
+			items = append(items, p)
+		}
+	default:
+		return fmt.Errorf("cannot convert %T to %s", cell, dst.Type())
+	}
+	for i, idx := range fields {
+		if i >= len(items) {
+			break
+		}
+		if err := d.setValue(dst.Field(idx), items[i], depth+1); err != nil {
+			return fmt.Errorf("%s.%s: %w", dst.Type().Name(), dst.Type().Field(idx).Name, err)
+		}
+	}
+	return nil
+}
+
+// tupleFields returns the indexes of the exported, non-skipped fields of t.
+func tupleFields(t reflect.Type) []int {
+	var out []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && f.Tag.Get("livestatus") != "-" {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// cellList turns a list cell into its elements. CSV lists are split on the list
+// separator at the top level and on the host/service separator when nested.
+func (d *Decoder) cellList(cell any, depth int) ([]any, error) {
+	switch t := cell.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return t, nil
+	case string:
+		if t == "" {
+			return []any{}, nil
+		}
+		sep := d.seps.list
+		if depth > 0 {
+			sep = d.seps.hostService
+		}
+		parts := strings.Split(t, string(sep))
+		out := make([]any, len(parts))
+		for i, p := range parts {
+			out[i] = p
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to list", cell)
+	}
+}
+
+// cellDict turns a dict cell into a map. In CSV, dicts are rendered as a list of
+// key/value pairs joined by the host/service separator.
+func (d *Decoder) cellDict(cell any) (map[string]any, error) {
+	switch t := cell.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return t, nil
+	case string:
+		out := map[string]any{}
+		if t == "" {
+			return out, nil
+		}
+		for _, pair := range strings.Split(t, string(d.seps.list)) {
+			k, v, _ := strings.Cut(pair, string(d.seps.hostService))
+			out[k] = v
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to dict", cell)
+	}
+}
+
+func cellBool(v any) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case nil:
+		return false, nil
+	}
+	n, err := cellInt(v)
+	if err != nil {
+		return false, err
+	}
+	return n != 0, nil
+}
+
+func cellInt(v any) (int64, error) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = strings.TrimSpace(t)
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to integer", v)
+	}
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	// Some integer columns are rendered as floats (e.g. "1e+06" or "3.0").
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int64(f)) {
+		return 0, fmt.Errorf("invalid integer %q", s)
+	}
+	return int64(f), nil
+}
+
+// ---------- tuple types ----------
+
+// HostServicePair is an element of service lists such as hostgroups.services
+// or contacts' service lists ("host|service" in CSV).
+type HostServicePair struct {
+	Host    string
+	Service string
+}
+
+// HostWithState is an element of hostgroups.members_with_state.
+type HostWithState struct {
+	Name           string
+	State          int
+	HasBeenChecked bool
+}
+
+// ServiceWithState is an element of hosts.services_with_state.
+type ServiceWithState struct {
+	Description    string
+	State          int
+	HasBeenChecked bool
+}
+
+// ServiceWithInfo is an element of hosts.services_with_info.
+type ServiceWithInfo struct {
+	Description    string
+	State          int
+	HasBeenChecked bool
+	PluginOutput   string
+}
@@ -0,0 +1,149 @@
+package livestatus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testHost struct {
+	Name      string            `livestatus:"name"`
+	State     int               `livestatus:"state"`
+	Latency   float64           `livestatus:"latency"`
+	Acked     bool              `livestatus:"acknowledged"`
+	LastCheck time.Time         `livestatus:"last_check"`
+	Parents   []string          `livestatus:"parents"`
+	Vars      map[string]string `livestatus:"custom_variables"`
+	Address   *string           // untagged: matched by field name
+	Ignored   string            `livestatus:"-"`
+}
+
+var testHostColumns = []string{"name", "state", "latency", "acknowledged", "last_check", "parents", "custom_variables", "address"}
+
+func TestUnmarshalFormats(t *testing.T) {
+	bodies := map[OutputFormat]string{
+		OutputCSV:  "web01;2;0.5;1;1724000000;gw1,gw2;ROLE|web,ENV|prod;10.0.0.1\n",
+		OutputJSON: `[["web01",2,0.5,1,1724000000,["gw1","gw2"],{"ROLE":"web","ENV":"prod"},"10.0.0.1"]]`,
+		OutputPY:   `[[u"web01",2,0.5,1,1724000000,[u"gw1",u"gw2"],{u"ROLE":u"web",u"ENV":u"prod"},u"10.0.0.1"]]`,
+	}
+	for format, body := range bodies {
+		t.Run(string(format), func(t *testing.T) {
+			is := is.New(t)
+			q := NewLiveStatusQuery(Table("hosts"), testHostColumns...).OutputFormat(format)
+
+			var hosts []testHost
+			is.NoErr(Unmarshal(q, []byte(body), &hosts))
+			is.Equal(len(hosts), 1)
+			h := hosts[0]
+			is.Equal(h.Name, "web01")
+			is.Equal(h.State, 2)
+			is.Equal(h.Latency, 0.5)
+			is.True(h.Acked)
+			is.Equal(h.LastCheck, time.Unix(1724000000, 0))
+			is.Equal(h.Parents, []string{"gw1", "gw2"})
+			is.Equal(h.Vars, map[string]string{"ROLE": "web", "ENV": "prod"})
+			is.True(h.Address != nil)
+			is.Equal(*h.Address, "10.0.0.1")
+		})
+	}
+}
+
+func TestUnmarshalColumnHeaders(t *testing.T) {
+	is := is.New(t)
+
+	// Column order comes from the header row, not the query.
+	q := NewLiveStatusQuery(Table("hosts"), "name", "state").ColumnHeaders(true)
+	body := "state;name;extra\n0;db01;x\n1;db02;y\n"
+
+	var hosts []*testHost
+	is.NoErr(Unmarshal(q, []byte(body), &hosts))
+	is.Equal(len(hosts), 2)
+	is.Equal(hosts[1].Name, "db02")
+	is.Equal(hosts[1].State, 1)
+	is.True(hosts[1].LastCheck.IsZero())
+	is.Equal(len(hosts[0].Parents), 0)
+}
+
+func TestUnmarshalStatsColumns(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("services"), "host_name").
+		Stats("state", OpEq, "2").StatsAs("crit")
+	var rows []struct {
+		Host string `livestatus:"host_name"`
+		Crit int    `livestatus:"crit"`
+	}
+	is.NoErr(Unmarshal(q, []byte("web01;4\n"), &rows))
+	is.Equal(rows[0].Host, "web01")
+	is.Equal(rows[0].Crit, 4)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("hosts"), "name", "state")
+
+	var hosts []testHost
+	is.True(Unmarshal(q, []byte("web01;notanint\n"), &hosts) != nil)
+	is.True(Unmarshal(q, []byte("web01;1\n"), hosts) != nil)       // not a pointer
+	is.True(Unmarshal(q, []byte("web01;1\n"), &[]string{}) != nil) // not structs
+
+	errBoom := errors.New("boom")
+	res := &Result{StatusCode: StatusBadRequest, Error: errBoom}
+	is.Equal(res.Unmarshal(q, &hosts), errBoom)
+}
+
+type testHostServices struct {
+	Name     string             `livestatus:"name"`
+	Services []ServiceWithState `livestatus:"services_with_state"`
+	Info     []ServiceWithInfo  `livestatus:"services_with_info"`
+	Contacts []string           `livestatus:"contacts"`
+}
+
+func TestUnmarshalTuples(t *testing.T) {
+	bodies := map[OutputFormat]string{
+		OutputCSV:  "web01;HTTP|2|1,SSH|0|0;HTTP|2|1|CRIT - 500|time=3s;alice,bob\n",
+		OutputJSON: `[["web01",[["HTTP",2,1],["SSH",0,0]],[["HTTP",2,1,"CRIT - 500|time=3s",""]],["alice","bob"]]]`,
+		OutputPY:   `[[u"web01",[[u"HTTP",2,1],[u"SSH",0,0]],[[u"HTTP",2,1,u"CRIT - 500|time=3s"]],[u"alice",u"bob"]]]`,
+	}
+	for format, body := range bodies {
+		t.Run(string(format), func(t *testing.T) {
+			is := is.New(t)
+			q := NewLiveStatusQuery(Table("hosts"), "name", "services_with_state", "services_with_info", "contacts").OutputFormat(format)
+
+			var hosts []testHostServices
+			is.NoErr(Unmarshal(q, []byte(body), &hosts))
+			is.Equal(hosts[0].Services, []ServiceWithState{{"HTTP", 2, true}, {"SSH", 0, false}})
+			// The last tuple field keeps the separator; extra JSON elements are ignored.
+			is.Equal(hosts[0].Info, []ServiceWithInfo{{"HTTP", 2, true, "CRIT - 500|time=3s"}})
+			is.Equal(hosts[0].Contacts, []string{"alice", "bob"})
+		})
+	}
+}
+
+func TestUnmarshalCustomSeparators(t *testing.T) {
+	is := is.New(t)
+
+	// Unit/record separators keep commas, semicolons and pipes in values intact.
+	q := NewLiveStatusQuery(Table("hosts"), "name", "services_with_info", "contacts").
+		Separators(0x1e, 0x1f, 0x1d, 0x1c)
+	body := "web;01\x1fHTTP\x1c2\x1c1\x1cCRIT, 500; see | perf\x1dSSH\x1c0\x1c1\x1cOK\x1fops, oncall\x1dbob\x1e" +
+		"db01\x1f\x1f\x1e"
+
+	var hosts []testHostServices
+	is.NoErr(Unmarshal(q, []byte(body), &hosts))
+	is.Equal(len(hosts), 2)
+	is.Equal(hosts[0].Name, "web;01")
+	is.Equal(hosts[0].Info, []ServiceWithInfo{{"HTTP", 2, true, "CRIT, 500; see | perf"}, {"SSH", 0, true, "OK"}})
+	is.Equal(hosts[0].Contacts, []string{"ops, oncall", "bob"})
+	is.Equal(hosts[1].Name, "db01")
+	is.Equal(len(hosts[1].Info), 0)
+
+	var pairs []struct {
+		Members []HostServicePair `livestatus:"members"`
+	}
+	q = NewLiveStatusQuery(Table("servicegroups"), "members").Separators('\n', ';', ',', '|')
+	is.NoErr(Unmarshal(q, []byte("web01|HTTP,db01|MySQL\n"), &pairs))
+	is.Equal(pairs[0].Members, []HostServicePair{{"web01", "HTTP"}, {"db01", "MySQL"}})
+}
@@ -0,0 +1,106 @@
+package livestatus
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+)
+
+// Dialer opens a connection to a Livestatus endpoint. Set LiveStatusConfig.Dialer
+// to reach sites through jump hosts, network namespaces or in-process test
+// doubles; both the actor and the one-off functions dial through it.
+//
+// ctx carries ConnectTimeout as its deadline, so implementations only need to
+// honour the context.
+type Dialer interface {
+	DialContext(ctx context.Context) (net.Conn, error)
+}
+
+// DialerFunc adapts a function to the Dialer interface.
+type DialerFunc func(ctx context.Context) (net.Conn, error)
+
+// DialContext calls f(ctx).
+func (f DialerFunc) DialContext(ctx context.Context) (net.Conn, error) { return f(ctx) }
+
+// UnixDialer connects to a Unix socket.
+type UnixDialer struct {
+	Path string
+}
+
+// DialContext connects to the socket at d.Path.
+func (d UnixDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	var nd net.Dialer
+	return nd.DialContext(ctx, "unix", d.Path)
+}
+
+// TCPDialer connects over plain TCP.
+type TCPDialer struct {
+	Address string // host:port
+}
+
+// DialContext connects to d.Address.
+func (d TCPDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	var nd net.Dialer
+	return nd.DialContext(ctx, "tcp", d.Address)
+}
+
+// TLSDialer runs TLS over a connection from Transport, or over TCP to Address
+// when Transport is nil.
+type TLSDialer struct {
+	Address   string      // host:port; its host is the default server name
+	Config    *tls.Config // nil means the system defaults
+	Transport Dialer      // optional underlying connection, e.g. through a jump host
+}
+
+// DialContext connects and completes the TLS handshake.
+func (d TLSDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	transport := d.Transport
+	if transport == nil {
+		transport = TCPDialer{Address: d.Address}
+	}
+	raw, err := transport.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conf := &tls.Config{}
+	if d.Config != nil {
+		conf = d.Config.Clone()
+	}
+	if conf.ServerName == "" && !conf.InsecureSkipVerify {
+		host, _, err := net.SplitHostPort(d.Address)
+		if err != nil {
+			host = d.Address
+		}
+		conf.ServerName = host
+	}
+	conn := tls.Client(raw, conf)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		_ = raw.Close()
+		return nil, fmt.Errorf("tls handshake: %w", err)
+	}
+	return conn, nil
+}
+
+// dialer returns cfg.Dialer, or the built-in dialer for Network, Address and
+// the TLS settings.
+func (c *LiveStatusConfig) dialer() (Dialer, error) {
+	if c.Dialer != nil {
+		return c.Dialer, nil
+	}
+	c, network, err := c.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		return UnixDialer{Path: c.Address}, nil
+	}
+	if c.UseTLS {
+		tlsConf, err := buildTLSConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		return TLSDialer{Address: c.Address, Config: tlsConf}, nil
+	}
+	return TCPDialer{Address: c.Address}, nil
+}
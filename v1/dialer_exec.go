@@ -0,0 +1,240 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CommandDialer reaches Livestatus through a spawned command whose stdin and
+// stdout carry the query stream, e.g.
+//
+//	livestatus.CommandDialer{Path: "ssh", Args: []string{"site@monitor", "unixcat ~/tmp/run/live"}}
+//
+// Every dial starts a new process; closing the connection closes its stdin and
+// kills it if it doesn't exit on its own. When the process exits by itself,
+// reads and writes fail with a *ProcessExitError holding its exit status and
+// the end of its stderr, and the actor publishes a ConnectivityEvent with
+// Reason "process_exit".
+type CommandDialer struct {
+	Path string
+	Args []string
+	Env  []string // nil inherits the current environment
+	Dir  string
+}
+
+// DialContext starts the command. The process outlives ctx: it only bounds
+// the start-up.
+func (d CommandDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, err
+	}
+	c := &commandConn{
+		name:   strings.Join(append([]string{d.Path}, d.Args...), " "),
+		stdin:  stdinW,
+		stdout: stdoutR,
+		stderr: &tailBuffer{max: stderrTail},
+		done:   make(chan struct{}),
+	}
+	c.cmd = exec.Command(d.Path, d.Args...)
+	c.cmd.Env = d.Env
+	c.cmd.Dir = d.Dir
+	c.cmd.Stdin = stdinR
+	c.cmd.Stdout = stdoutW
+	c.cmd.Stderr = c.stderr
+	err = c.cmd.Start()
+	// The child holds its own copies of these ends.
+	stdinR.Close()
+	stdoutW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		return nil, fmt.Errorf("starting %q: %w", c.name, err)
+	}
+	go c.wait()
+	return c, nil
+}
+
+// stderrTail is how much of a command's stderr is kept for ProcessExitError.
+const stderrTail = 4 << 10
+
+// exitGrace is how long Close waits for the process to exit after its stdin
+// is closed, and how long a read at EOF waits for the exit status.
+const exitGrace = time.Second
+
+// ProcessExitError reports that the process behind a CommandDialer connection
+// exited.
+type ProcessExitError struct {
+	Command  string
+	ExitCode int    // -1 if the process was killed by a signal
+	Stderr   string // the last few KiB of its stderr
+	Err      error  // as returned by exec.Cmd.Wait
+}
+
+func (e *ProcessExitError) Error() string {
+	msg := fmt.Sprintf("command %q exited with status %d", e.Command, e.ExitCode)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *ProcessExitError) Unwrap() error { return e.Err }
+
+// processConn is implemented by connections backed by a process, so the actor
+// can report the process exiting on its own.
+type processConn interface {
+	exited() <-chan struct{}
+	// exitErr returns the *ProcessExitError, or nil if the process was stopped
+	// by Close.
+	exitErr() error
+}
+
+// commandConn adapts a running command to net.Conn. Deadlines are supported
+// since the pipes are pollable.
+type commandConn struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  *os.File
+	stdout *os.File
+	stderr *tailBuffer
+
+	closing   atomic.Bool
+	closeOnce sync.Once
+
+	done     chan struct{} // closed once the process has exited
+	err      *ProcessExitError
+	byItself bool // exited before Close was called
+}
+
+func (c *commandConn) wait() {
+	err := c.cmd.Wait()
+	c.byItself = !c.closing.Load()
+	c.err = &ProcessExitError{
+		Command:  c.name,
+		ExitCode: c.cmd.ProcessState.ExitCode(),
+		Stderr:   strings.TrimSpace(c.stderr.String()),
+		Err:      err,
+	}
+	close(c.done)
+}
+
+func (c *commandConn) exited() <-chan struct{} { return c.done }
+
+func (c *commandConn) exitErr() error {
+	<-c.done
+	if !c.byItself {
+		return nil
+	}
+	return c.err
+}
+
+// ioError maps pipe errors onto what the connection code expects from a
+// net.Conn: deadline expiry as a net.Error timeout, and the process exit
+// rather than a bare EOF or EPIPE.
+func (c *commandConn) ioError(err error) error {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return os.ErrDeadlineExceeded
+	}
+	if c.closing.Load() {
+		return net.ErrClosed
+	}
+	select {
+	case <-c.done:
+		return c.err
+	case <-time.After(exitGrace):
+		return err
+	}
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err != nil {
+		err = c.ioError(err)
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	n, err := c.stdin.Write(p)
+	if err != nil {
+		err = c.ioError(err)
+	}
+	return n, err
+}
+
+// Close closes stdin, which makes tools like unixcat and ssh exit, and kills
+// the process if it is still running after a grace period.
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.closing.Store(true)
+		_ = c.stdin.Close()
+		_ = c.stdout.Close()
+		select {
+		case <-c.done:
+		case <-time.After(exitGrace):
+			_ = c.cmd.Process.Kill()
+			<-c.done
+		}
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr(c.name) }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr(c.name) }
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return c.stdout.SetReadDeadline(t) }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return c.stdin.SetWriteDeadline(t) }
+
+// commandAddr names the command as the address of its connection.
+type commandAddr string
+
+func (a commandAddr) Network() string { return "exec" }
+func (a commandAddr) String() string  { return string(a) }
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
@@ -0,0 +1,142 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubLivestatus returns a CommandDialer running a shell loop that answers
+// every request with a fixed16 frame holding body, followed by script.
+func stubLivestatus(t *testing.T, body, script string) CommandDialer {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	frame := fmt.Sprintf("%03d %11d\n%s", StatusOK, len(body), body)
+	loop := `while IFS= read -r line; do [ -z "$line" ] && { printf '%s' "$1"; ` + script + ` }; done`
+	return CommandDialer{Path: "sh", Args: []string{"-c", loop, "stub", frame}}
+}
+
+func TestCommandDialerOneOff(t *testing.T) {
+	is := is.New(t)
+
+	config := NewLiveStatusConfig("ignored")
+	config.Dialer = stubLivestatus(t, `[["web01"]]`, ":;")
+	res, err := QueryOneOffFromBuilder(context.Background(), NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON), config)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+	is.Equal(string(res.Data), `[["web01"]]`)
+}
+
+func TestCommandDialerStderrInError(t *testing.T) {
+	is := is.New(t)
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+
+	config := NewLiveStatusConfig("ignored")
+	config.Dialer = CommandDialer{Path: "sh", Args: []string{"-c", "echo 'site@monitor: Permission denied (publickey).' >&2; exit 255"}}
+	_, err := QueryOneOff(context.Background(), "GET status", config)
+	is.True(err != nil)
+	var pe *ProcessExitError
+	is.True(errors.As(err, &pe))
+	is.Equal(pe.ExitCode, 255)
+	is.Equal(pe.Stderr, "site@monitor: Permission denied (publickey).")
+}
+
+func TestCommandDialerActorProcessExit(t *testing.T) {
+	is := is.New(t)
+
+	// The stub answers once, complains and exits.
+	config := NewLiveStatusConfig("ignored")
+	config.Dialer = stubLivestatus(t, `[["web01"]]`, "echo 'broken pipe' >&2; exit 3;")
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 2)
+	events := make(chan ConnectivityEvent, 16)
+	actor := NewLiveStatusActor(logger, "test_exec", config, 4, results, prometheus.NewRegistry())
+	actor.SetEventChan(events)
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	query := *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON)
+	_, err := actor.SendQuery(context.Background(), query)
+	is.NoErr(err)
+	msg := recvResult(t, results, 2*time.Second)
+	is.NoErr(msg.Result.Error)
+
+	deadline := time.After(2 * time.Second)
+	for {
+		var ev ConnectivityEvent
+		select {
+		case ev = <-events:
+		case <-deadline:
+			t.Fatal("no process_exit event")
+		}
+		if ev.Reason != "process_exit" {
+			continue
+		}
+		is.Equal(ev.State, StateDisconnected)
+		var pe *ProcessExitError
+		is.True(errors.As(ev.Err, &pe))
+		is.Equal(pe.ExitCode, 3)
+		is.Equal(pe.Stderr, "broken pipe")
+		break
+	}
+
+	// The next request notices the dead process and starts a new one.
+	_, err = actor.SendQuery(context.Background(), query)
+	is.NoErr(err)
+	msg = recvResult(t, results, 2*time.Second)
+	is.NoErr(msg.Result.Error)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+}
+
+func TestCommandDialerPersistentConn(t *testing.T) {
+	is := is.New(t)
+
+	config := NewLiveStatusConfig("ignored")
+	config.Dialer = stubLivestatus(t, `[["web01"]]`, ":;")
+
+	conn, reader, err := ensureConn(context.Background(), config, nil)
+	is.NoErr(err)
+	defer conn.Close()
+	logger := slog.New(slog.DiscardHandler)
+	for range 3 {
+		res, err := execOverPersistentConn(logger, context.Background(), config, conn, reader, *NewLiveStatusQuery(Table("hosts"), "name"))
+		is.NoErr(err)
+		is.Equal(string(res.Data), `[["web01"]]`)
+		// The liveness probe keeps a running process.
+		again, r, err := ensureConn(context.Background(), config, conn)
+		is.NoErr(err)
+		is.Equal(again, conn)
+		reader = r
+	}
+}
+
+func TestCommandDialerCloseKillsProcess(t *testing.T) {
+	is := is.New(t)
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep available")
+	}
+
+	conn, err := CommandDialer{Path: "sleep", Args: []string{"30"}}.DialContext(context.Background())
+	is.NoErr(err)
+	start := time.Now()
+	is.NoErr(conn.Close())
+	is.True(time.Since(start) < 5*time.Second)
+	select {
+	case <-conn.(processConn).exited():
+	default:
+		t.Fatal("process still running after Close")
+	}
+	is.NoErr(conn.(processConn).exitErr()) // stopped by Close, not an unexpected exit
+}
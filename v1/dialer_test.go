@@ -0,0 +1,182 @@
+package livestatus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pipeDialer serves every dial with an in-process fixed16 responder.
+func pipeDialer(dials *atomic.Int32, body string) Dialer {
+	return DialerFunc(func(ctx context.Context) (net.Conn, error) {
+		dials.Add(1)
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			buf := make([]byte, 4096)
+			var req strings.Builder
+			for !strings.HasSuffix(req.String(), "\n\n") {
+				n, err := server.Read(buf)
+				if err != nil {
+					return
+				}
+				req.Write(buf[:n])
+			}
+			_, _ = fmt.Fprintf(server, "%03d %11d\n%s", StatusOK, len(body), body)
+		}()
+		return client, nil
+	})
+}
+
+func TestCustomDialerOneOff(t *testing.T) {
+	is := is.New(t)
+
+	var dials atomic.Int32
+	config := NewLiveStatusConfig("ignored:1")
+	config.Dialer = pipeDialer(&dials, `[["web01"]]`)
+
+	res, err := QueryOneOff(context.Background(), "GET hosts\nColumns: name\nOutputFormat: json", config)
+	is.NoErr(err)
+	is.Equal(string(res.Data), `[["web01"]]`)
+	is.Equal(dials.Load(), int32(1))
+}
+
+func TestCustomDialerActor(t *testing.T) {
+	is := is.New(t)
+
+	var dials atomic.Int32
+	config := NewLiveStatusConfig("ignored:1")
+	config.Dialer = pipeDialer(&dials, `[["web01"]]`)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 1)
+	actor := NewLiveStatusActor(logger, "test_dialer", config, 4, results, prometheus.NewRegistry())
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	id, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON))
+	is.NoErr(err)
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.NoErr(msg.Result.Error)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+	is.True(dials.Load() >= 1)
+}
+
+func TestDialerHonoursConnectTimeout(t *testing.T) {
+	is := is.New(t)
+
+	config := NewLiveStatusConfig("ignored:1")
+	config.ConnectTimeout = 50 * time.Millisecond
+	config.Dialer = DialerFunc(func(ctx context.Context) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	_, err := QueryOneOff(context.Background(), "GET hosts", config)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "failed to connect"))
+	is.True(time.Since(start) < time.Second)
+}
+
+func TestTLSDialer(t *testing.T) {
+	is := is.New(t)
+
+	cert, pool := selfSignedCert(t, "monitor.test")
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	is.NoErr(err)
+	defer ln.Close()
+	go serveOnce(ln)
+
+	// The listener is reached through a custom transport; the certificate is
+	// verified against the configured server name, not the dialed address.
+	d := TLSDialer{
+		Address:   "monitor.test:6557",
+		Config:    &tls.Config{RootCAs: pool},
+		Transport: TCPDialer{Address: ln.Addr().String()},
+	}
+	config := NewLiveStatusConfig("ignored:1")
+	config.Dialer = d
+	res, err := QueryOneOff(context.Background(), "GET status", config)
+	is.NoErr(err)
+	is.Equal(string(res.Data), "ok")
+
+	// A name the certificate doesn't cover fails the handshake.
+	go serveOnce(ln)
+	d.Address = "other.test:6557"
+	config.Dialer = d
+	_, err = QueryOneOff(context.Background(), "GET status", config)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "tls handshake"))
+}
+
+func TestBuiltinDialers(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		config *LiveStatusConfig
+		want   Dialer
+	}{
+		{NewLiveStatusConfig("/tmp/live"), UnixDialer{Path: "/tmp/live"}},
+		{NewLiveStatusConfig("unix:///tmp/live"), UnixDialer{Path: "/tmp/live"}},
+		{NewLiveStatusConfig("localhost:6557"), TCPDialer{Address: "localhost:6557"}},
+		{NewLiveStatusConfig("tcp://[::1]"), TCPDialer{Address: "[::1]:6557"}},
+	}
+	for _, tt := range tests {
+		d, err := tt.config.dialer()
+		is.NoErr(err)
+		is.Equal(d, tt.want) // dialer for tt.config.Address
+	}
+
+	d, err := NewLiveStatusConfig("tls://monitor?servername=live.example.com").dialer()
+	is.NoErr(err)
+	td, ok := d.(TLSDialer)
+	is.True(ok)
+	is.Equal(td.Address, "monitor:6557")
+	is.Equal(td.Config.ServerName, "live.example.com")
+}
+
+// selfSignedCert returns a certificate for name and a pool trusting it.
+func selfSignedCert(t *testing.T, name string) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
@@ -20,6 +20,7 @@ const (
 // ConnectivityEvent describes a connection lifecycle update.
 type ConnectivityEvent struct {
 	Actor   string
+	Conn    int // index of the pooled connection (see LiveStatusActor.SetPoolSize)
 	State   ConnectivityState
 	Time    time.Time
 	Reason  string
@@ -48,6 +49,7 @@ func (s ConnectivityState) String() string {
 // to keep the wire format stable and user-friendly.
 type connectivityEventJSON struct {
 	Actor   string    `json:"actor,omitempty"`
+	Conn    int       `json:"conn"`
 	State   string    `json:"state"`
 	Time    time.Time `json:"time"`
 	Reason  string    `json:"reason,omitempty"`
@@ -61,6 +63,7 @@ type connectivityEventJSON struct {
 func (e ConnectivityEvent) ToJSON() string {
 	ce := connectivityEventJSON{
 		Actor:   e.Actor,
+		Conn:    e.Conn,
 		State:   e.State.String(),
 		Time:    e.Time,
 		Reason:  e.Reason,
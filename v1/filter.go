@@ -0,0 +1,132 @@
+package livestatus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a node of a filter expression tree. Trees are built with Eq, Re, And,
+// Or, Not, ... and compiled into the postfix LQL stack form by the query builder,
+// so callers never have to count operands for And:/Or: by hand.
+//
+// The same tree can be rendered as Filter: lines (FilterExpr) or as
+// WaitCondition: lines (WaitConditionExpr).
+type Expr interface {
+	// appendLines appends the postfix LQL lines for this node to dst.
+	appendLines(dst []string, d exprDialect) []string
+}
+
+// exprDialect names the header keywords used when compiling an Expr.
+type exprDialect struct {
+	leaf   string // e.g. "Filter"
+	and    string // e.g. "And"
+	or     string // e.g. "Or"
+	negate string // e.g. "Negate"
+}
+
+var (
+	filterDialect = exprDialect{leaf: "Filter", and: "And", or: "Or", negate: "Negate"}
+	waitDialect   = exprDialect{leaf: "WaitCondition", and: "WaitConditionAnd", or: "WaitConditionOr", negate: "WaitConditionNegate"}
+)
+
+// condExpr is a single "column op value" comparison.
+type condExpr struct {
+	column string
+	op     Op
+	value  string
+}
+
+func (c condExpr) appendLines(dst []string, d exprDialect) []string {
+	return append(dst, fmt.Sprintf("%s: %s %s %s", d.leaf, safeToken(c.column), c.op, safeValue(c.value)))
+}
+
+// boolExpr combines its children with And or Or.
+type boolExpr struct {
+	and      bool
+	children []Expr
+}
+
+func (b boolExpr) appendLines(dst []string, d exprDialect) []string {
+	// A single child needs no glue; "And: 1" would be a no-op.
+	if len(b.children) == 1 {
+		return b.children[0].appendLines(dst, d)
+	}
+	for _, c := range b.children {
+		dst = c.appendLines(dst, d)
+	}
+	// Zero children is still meaningful: "And: 0" matches everything, "Or: 0" nothing.
+	key := d.or
+	if b.and {
+		key = d.and
+	}
+	return append(dst, fmt.Sprintf("%s: %d", key, len(b.children)))
+}
+
+// notExpr negates its child.
+type notExpr struct {
+	child Expr
+}
+
+func (n notExpr) appendLines(dst []string, d exprDialect) []string {
+	dst = n.child.appendLines(dst, d)
+	return append(dst, d.negate+":")
+}
+
+// Cond builds a generic "column op value" comparison.
+func Cond(column string, op Op, value string) Expr {
+	return condExpr{column: column, op: op, value: value}
+}
+
+// Convenience constructors mirroring the FilterXxx helpers:
+
+func Eq(column, value string) Expr    { return Cond(column, OpEq, value) }
+func Ne(column, value string) Expr    { return Cond(column, OpNe, value) }
+func Lt(column, value string) Expr    { return Cond(column, OpLt, value) }
+func Le(column, value string) Expr    { return Cond(column, OpLe, value) }
+func Gt(column, value string) Expr    { return Cond(column, OpGt, value) }
+func Ge(column, value string) Expr    { return Cond(column, OpGe, value) }
+func Re(column, pattern string) Expr  { return Cond(column, OpRe, pattern) }
+func ReI(column, pattern string) Expr { return Cond(column, OpReIC, pattern) }
+
+// And matches when all sub-expressions match. nil entries are ignored.
+// And() with no arguments matches everything.
+func And(exprs ...Expr) Expr {
+	return boolExpr{and: true, children: compact(exprs)}
+}
+
+// Or matches when at least one sub-expression matches. nil entries are ignored.
+// Or() with no arguments matches nothing.
+func Or(exprs ...Expr) Expr {
+	return boolExpr{and: false, children: compact(exprs)}
+}
+
+// Not negates an expression. Not(nil) is equivalent to And() negated, i.e. matches nothing.
+func Not(e Expr) Expr {
+	if e == nil {
+		e = And()
+	}
+	return notExpr{child: e}
+}
+
+// compileExpr renders e as postfix LQL lines using the given dialect.
+func compileExpr(e Expr, d exprDialect) []string {
+	if e == nil {
+		return nil
+	}
+	return e.appendLines(nil, d)
+}
+
+// ExprString renders e as Filter: lines joined by newlines; handy for logging and tests.
+func ExprString(e Expr) string {
+	return strings.Join(compileExpr(e, filterDialect), "\n")
+}
+
+func compact(exprs []Expr) []Expr {
+	out := make([]Expr, 0, len(exprs))
+	for _, e := range exprs {
+		if e != nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package livestatus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFilterExprCompile(t *testing.T) {
+	is := is.New(t)
+
+	e := And(
+		Eq("state", "2"),
+		Or(Re("host_name", "^web"), Not(Eq("acknowledged", "1"))),
+	)
+	q := NewLiveStatusQuery(Table("services"), "host_name").FilterExpr(e)
+
+	got := strings.Split(strings.TrimSuffix(q.Build(), "\n"), "\n")
+	want := []string{
+		"GET services",
+		"Columns: host_name",
+		"Filter: state = 2",
+		"Filter: host_name ~ ^web",
+		"Filter: acknowledged = 1",
+		"Negate:",
+		"Or: 2",
+		"And: 2",
+	}
+	is.Equal(got, want)
+}
+
+func TestFilterExprEdgeCases(t *testing.T) {
+	is := is.New(t)
+
+	// Single child needs no glue, nil children are dropped.
+	is.Equal(ExprString(And(nil, Eq("state", "0"))), "Filter: state = 0")
+	// Empty combinations are still valid LQL.
+	is.Equal(ExprString(And()), "And: 0")
+	is.Equal(ExprString(Or()), "Or: 0")
+	is.Equal(ExprString(nil), "")
+	// Values are sanitized like the FilterXxx helpers.
+	is.Equal(ExprString(Eq("host_name", "bad\r\nname")), "Filter: host_name = bad  name")
+}
+
+func TestWaitConditionExpr(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("hosts")).
+		WaitTrigger("state").
+		WaitObject("myhost").
+		WaitConditionExpr(Or(Eq("state", "0"), Not(Gt("last_check", "100")))).
+		WaitTimeout(1000)
+
+	got := strings.Split(strings.TrimSuffix(q.Build(), "\n"), "\n")
+	want := []string{
+		"GET hosts",
+		"WaitTrigger: state",
+		"WaitObject: myhost",
+		"WaitCondition: state = 0",
+		"WaitCondition: last_check > 100",
+		"WaitConditionNegate:",
+		"WaitConditionOr: 2",
+		"WaitTimeout: 1000",
+	}
+	is.Equal(got, want)
+}
@@ -1,15 +1,12 @@
 package livestatus
 
 import (
-	"bufio"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"io"
 	"net"
 	"os"
-	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -45,6 +42,8 @@ const (
 	StatusUnauthorized        = 401
 	StatusForbidden           = 403
 	StatusNotFound            = 404
+	StatusRequestTimeout      = 408 // set by the actor: the request's deadline passed
+	StatusClientClosedRequest = 499 // set by the actor: the request was cancelled
 	StatusInternalServerError = 500
 	StatusServiceUnavailable  = 503
 )
@@ -56,6 +55,8 @@ var statusText = map[int]string{
 	StatusUnauthorized:        "Unauthorized",
 	StatusForbidden:           "Forbidden",
 	StatusNotFound:            "Not Found",
+	StatusRequestTimeout:      "Request Timeout",
+	StatusClientClosedRequest: "Client Closed Request",
 	StatusInternalServerError: "Internal Server Error",
 	StatusServiceUnavailable:  "Service Unavailable",
 }
@@ -89,12 +90,25 @@ type ResultMsg struct {
 type WorkItem struct {
 	ID    RequestID
 	Query LiveStatusQuery
+	// Command, when set, is sent instead of Query. Commands get no response;
+	// their Result carries StatusOK and no data once the command is written.
+	Command *Command
+	// Deadline, when set, bounds the request like a context deadline: the item
+	// is skipped if it is still queued at the deadline, and socket reads and
+	// writes don't wait past it.
+	Deadline time.Time
+
+	ctx    context.Context // the submitter's context, attached by Enqueue
+	stream *rowStream      // set for streaming requests; rows go here instead of the results bus
+	future *Future         // set by Submit and Query; the result goes here instead of the results bus
 }
 
 // LiveStatusConfig holds configuration for direct livestatus connections
 type LiveStatusConfig struct {
 	// Connection details
-	Address string // TCP address (e.g., "localhost:6557") or Unix socket path
+	Address string // TCP address (e.g., "localhost:6557"), Unix socket path or address URI (see SetAddress)
+	// Network is "unix" or "tcp". Empty means it is inferred from Address.
+	Network string
 
 	// Optional settings
 	ConnectTimeout time.Duration // Time to wait for connection (default: 10s)
@@ -113,6 +127,13 @@ type LiveStatusConfig struct {
 	// CertFile and KeyFile optionally provide a client certificate for mTLS.
 	CertFile string
 	KeyFile  string
+	// ServerName is the name the server certificate is verified against.
+	// Defaults to the host part of Address.
+	ServerName string
+
+	// Dialer, when set, opens every connection instead of the built-in dialer
+	// for Address; Network and the TLS settings are then ignored.
+	Dialer Dialer
 }
 
 // NewLiveStatusConfig creates a new configuration with sensible defaults.
@@ -134,35 +155,25 @@ func NewWorkItemFromQuery(id RequestID, q *LiveStatusQuery) *WorkItem {
 	}
 }
 
+// NewWorkItemFromCommand wraps an external command into a WorkItem with the given ID.
+func NewWorkItemFromCommand(id RequestID, cmd *Command) *WorkItem {
+	return &WorkItem{ID: id, Command: cmd}
+}
+
 // ---------- Low-level Livestatus I/O (unchanged) ----------
 
 func connectToLiveStatus(ctx context.Context, cfg *LiveStatusConfig) (net.Conn, error) {
-	// Determine if it's a Unix socket or TCP connection
-	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
-	}
-
-	// Check if address looks like a Unix socket path (contains no colon)
-	if !strings.Contains(cfg.Address, ":") {
-		// Unix socket
-		return dialer.DialContext(ctx, "unix", cfg.Address)
-	}
-
-	// TCP connection
-	if cfg != nil && cfg.UseTLS {
-		tlsConf, err := buildTLSConfig(cfg)
-		if err != nil {
-			return nil, err
-		}
-		td := &tls.Dialer{NetDialer: dialer, Config: tlsConf}
-		return td.DialContext(ctx, "tcp", cfg.Address)
+	d, err := cfg.dialer()
+	if err != nil {
+		return nil, err
 	}
-	return dialer.DialContext(ctx, "tcp", cfg.Address)
+	return d.DialContext(ctx)
 }
 
 func buildTLSConfig(cfg *LiveStatusConfig) (*tls.Config, error) {
 	tlsConf := &tls.Config{
 		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.ServerName,
 	}
 
 	if cfg.CAFile != "" {
@@ -187,48 +198,3 @@ func buildTLSConfig(cfg *LiveStatusConfig) (*tls.Config, error) {
 
 	return tlsConf, nil
 }
-
-func sendQuery(conn net.Conn, query string) error {
-	// Ensure query ends with a blank line (livestatus protocol requirement)
-	if !strings.HasSuffix(query, "\n\n") {
-		if !strings.HasSuffix(query, "\n") {
-			query += "\n"
-		}
-		query += "\n"
-	}
-
-	// Send the query
-	_, err := conn.Write([]byte(query))
-	return err
-}
-
-func readResponse(conn net.Conn) ([]byte, error) {
-	var response []byte
-	reader := bufio.NewReader(conn)
-
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				// EOF is expected at end of response
-				response = append(response, line...)
-				break
-			}
-			return nil, fmt.Errorf("error reading response: %w", err)
-		}
-
-		response = append(response, line...)
-
-		// Check if we've reached the end of the response (empty line)
-		if len(line) == 1 && line[0] == '\n' {
-			// We found an empty line, which typically indicates end of response
-			// But we need to check if there's more data after it
-			if reader.Buffered() == 0 {
-				// No more buffered data, this is likely the end
-				break
-			}
-		}
-	}
-
-	return response, nil
-}
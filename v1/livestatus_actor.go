@@ -1,11 +1,9 @@
 package livestatus
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"log/slog"
-	"net"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -20,7 +18,7 @@ type LiveStatusActor struct {
 	logger   *slog.Logger
 	siteName string
 	metrics  *Metrics
-	config   *LiveStatusConfig
+	config   atomic.Pointer[LiveStatusConfig] // nil in simulation mode; see UpdateConfig
 
 	// Queue and processing
 	queue     chan *WorkItem
@@ -29,21 +27,39 @@ type LiveStatusActor struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 	closeOnce sync.Once
+	closed    atomic.Bool // set by Close before the queue is closed
 
 	// Configuration
 	queueCapacity int
+	poolSize      int
+	pipelineDepth int
 
-	// Persistent connection state (single worker => no extra locking required)
-	conn   net.Conn
-	reader *bufio.Reader
+	// Persistent connections, one per worker (see SetPoolSize)
+	conns []*actorConn
 
-	// Connectivity events and tracking
-	eventChan   chan<- ConnectivityEvent
-	activeSince time.Time
-	connState   atomic.Int32
+	// Submitted requests by ID, until their result is published (see Cancel)
+	reqMu    sync.Mutex
+	requests map[RequestID]*request
+
+	// Optional column catalog used to reject invalid queries before they are queued
+	schema *Schema
+
+	// Connectivity events
+	eventChan chan<- ConnectivityEvent
+
+	// Retries of failed requests (see SetRetryPolicy)
+	retry RetryPolicy
+
+	// Circuit breaker for the site, shared by all workers (see SetCircuitBreaker)
+	breaker circuit
+
+	// Results bus delivery (see SetDelivery)
+	delivery DeliveryOptions
+	ring     *resultRing // DeliverDropOldest only
 }
 
 // SendQuery creates a work item from the given query, enqueues it, and returns the request ID.
+// ctx bounds the request, not just the enqueueing (see Enqueue).
 func (a *LiveStatusActor) SendQuery(ctx context.Context, query LiveStatusQuery) (RequestID, error) {
 	id := nextRequestID()
 	item := NewWorkItemFromQuery(id, &query)
@@ -53,6 +69,19 @@ func (a *LiveStatusActor) SendQuery(ctx context.Context, query LiveStatusQuery)
 	return id, nil
 }
 
+// SendCommand enqueues an external command and returns its request ID. Its
+// Result (StatusOK, no data) is published once the command has been written.
+func (a *LiveStatusActor) SendCommand(ctx context.Context, cmd *Command) (RequestID, error) {
+	if cmd == nil {
+		return 0, fmt.Errorf("command cannot be nil")
+	}
+	id := nextRequestID()
+	if err := a.Enqueue(ctx, NewWorkItemFromCommand(id, cmd)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
 // TrySendQuery attempts to enqueue a query without blocking.
 // It returns the generated RequestID and true on success, or 0 and false if the queue is full.
 func (a *LiveStatusActor) TrySendQuery(query LiveStatusQuery) (RequestID, bool) {
@@ -67,7 +96,8 @@ func (a *LiveStatusActor) TrySendQuery(query LiveStatusQuery) (RequestID, bool)
 // NewLiveStatusActor creates a new livestatus actor.
 //
 // The 'results' channel is owned by the caller; the actor will publish
-// ResultMsg values to it but will never close it.
+// ResultMsg values to it but will never close it. It may be nil if all
+// requests go through Query or Submit, whose results never use it.
 func NewLiveStatusActor(
 	logger *slog.Logger,
 	siteName string,
@@ -80,9 +110,6 @@ func NewLiveStatusActor(
 	if queueCapacity <= 0 {
 		queueCapacity = 100 // Default capacity
 	}
-	if results == nil {
-		panic("results channel must not be nil")
-	}
 
 	actor := &LiveStatusActor{
 		logger:        logger.With("scope", "LiveStatusActor", "site", siteName),
@@ -92,7 +119,12 @@ func NewLiveStatusActor(
 		ctx:           nil,
 		cancel:        nil,
 		queueCapacity: queueCapacity,
-		config:        config,
+		poolSize:      1,
+		pipelineDepth: 1,
+		requests:      make(map[RequestID]*request),
+	}
+	if config != nil {
+		actor.config.Store(config)
 	}
 
 	// Create metrics
@@ -101,9 +133,6 @@ func NewLiveStatusActor(
 	// Set initial capacity metric
 	actor.metrics.SetQueueCapacity(queueCapacity)
 
-	// initial connectivity metrics
-	actor.metrics.SetClientConnected(0)
-
 	return actor
 }
 
@@ -112,12 +141,26 @@ func (a *LiveStatusActor) SetEventChan(ch chan<- ConnectivityEvent) {
 	a.eventChan = ch
 }
 
-func (a *LiveStatusActor) setState(s ConnectivityState) {
-	a.connState.Store(int32(s))
+// SetSchema enables up-front validation: Enqueue rejects queries that don't
+// match the schema with a *ValidationError, and TryEnqueue drops them.
+// Call it before Start.
+func (a *LiveStatusActor) SetSchema(s *Schema) {
+	a.schema = s
 }
 
-func (a *LiveStatusActor) emit(ev ConnectivityEvent) {
-	a.setState(ev.State)
+// validate checks the item against the configured schema, if any.
+func (a *LiveStatusActor) validate(item *WorkItem) error {
+	if a.schema == nil || item == nil || item.Command != nil {
+		return nil
+	}
+	return a.schema.Validate(&item.Query)
+}
+
+// emit records the state of c and publishes the event, tagged with the connection.
+func (a *LiveStatusActor) emit(c *actorConn, ev ConnectivityEvent) {
+	c.state.Store(int32(ev.State))
+	ev.Actor = a.siteName
+	ev.Conn = c.id
 	if a.eventChan == nil {
 		return
 	}
@@ -127,7 +170,8 @@ func (a *LiveStatusActor) emit(ev ConnectivityEvent) {
 	}
 }
 
-// Start begins the processing worker. It is safe to call only once; subsequent calls return an error.
+// Start begins the processing workers, one per pooled connection. It is safe
+// to call only once; subsequent calls return an error.
 func (a *LiveStatusActor) Start(ctx context.Context) error {
 	a.logger.Debug("Start")
 	if ctx == nil {
@@ -138,25 +182,69 @@ func (a *LiveStatusActor) Start(ctx context.Context) error {
 		return fmt.Errorf("actor already started")
 	}
 	a.ctx, a.cancel = context.WithCancel(ctx)
-	a.wg.Add(1)
-	go a.processLoop()
+	if a.delivery.Policy == DeliverDropOldest && a.results != nil {
+		a.ring = newResultRing(a.delivery.RingSize)
+		a.wg.Add(1)
+		go a.forwardResults()
+	}
+	a.conns = make([]*actorConn, a.poolSize)
+	for i := range a.conns {
+		c := newActorConn(i)
+		c.cfg = a.config.Load()
+		c.onProcessExit = func(err error) {
+			a.logger.Warn("transport process exited", "conn", c.id, "err", err)
+			a.metrics.IncrementReconnects("process_exit")
+			a.emit(c, ConnectivityEvent{State: StateDisconnected, Time: time.Now(), Reason: "process_exit", Err: err})
+		}
+		a.conns[i] = c
+		a.metrics.SetClientConnectedForConn(c.label, 0)
+		if a.pipelineDepth > 1 {
+			c.pipe = newConnPipeline(a.pipelineDepth)
+			a.wg.Add(1)
+			go a.readLoop(c)
+		}
+		a.wg.Add(1)
+		go a.processLoop(c)
+	}
 	return nil
 }
 
-// TryEnqueue attempts to enqueue a work item without blocking.
+// TryEnqueue attempts to enqueue a work item without blocking. The request is
+// bound to no context; only item.Deadline limits it.
 func (a *LiveStatusActor) TryEnqueue(item *WorkItem) bool {
+	if err := a.validate(item); err != nil {
+		a.logger.Warn("rejected invalid query", "id", item.ID, "err", err)
+		a.metrics.IncrementDropped("invalid_query")
+		return false
+	}
+	if a.closed.Load() {
+		a.metrics.IncrementDropped("actor_closed")
+		return false
+	}
+	a.track(item)
 	select {
 	case a.queue <- item:
 		a.metrics.IncrementEnqueued()
 		a.metrics.UpdateQueueLength(len(a.queue))
 		return true
 	default:
+		a.untrack(item.ID)
 		a.metrics.IncrementDropped("queue_full")
 		return false
 	}
 }
 
 // Enqueue enqueues a work item, blocking until space is available or context is cancelled.
+//
+// ctx stays attached to the item: if it is cancelled or its deadline passes
+// while the item is queued, the item is skipped, and while it runs, the
+// request is aborted. Either way the Result carries StatusClientClosedRequest
+// or StatusRequestTimeout; a running request whose response was already read
+// keeps it, except when pipelined (see SetPipelineDepth), where the abort is
+// reported regardless. Pass a context that lives as long as you wait for
+// the result; a context cancelled by a deferred cancel in the enqueuing
+// function aborts the request as soon as that function returns. TryEnqueue
+// attaches no context.
 func (a *LiveStatusActor) Enqueue(ctx context.Context, item *WorkItem) error {
 	// Fast-path: if caller's context is already canceled, respect it immediately
 	if ctx != nil {
@@ -165,6 +253,18 @@ func (a *LiveStatusActor) Enqueue(ctx context.Context, item *WorkItem) error {
 			return err
 		}
 	}
+	if err := a.validate(item); err != nil {
+		a.metrics.IncrementDropped("invalid_query")
+		return err
+	}
+	if a.closed.Load() {
+		a.metrics.IncrementDropped("actor_closed")
+		return ErrActorClosed
+	}
+	if item.ctx == nil {
+		item.ctx = ctx
+	}
+	a.track(item)
 	// If actor not started yet, still allow enqueue (items will be processed after Start)
 	if a.ctx == nil {
 		select {
@@ -173,6 +273,7 @@ func (a *LiveStatusActor) Enqueue(ctx context.Context, item *WorkItem) error {
 			a.metrics.UpdateQueueLength(len(a.queue))
 			return nil
 		case <-ctx.Done():
+			a.untrack(item.ID)
 			a.metrics.IncrementDropped("ctx_done")
 			return ctx.Err()
 		}
@@ -183,33 +284,71 @@ func (a *LiveStatusActor) Enqueue(ctx context.Context, item *WorkItem) error {
 		a.metrics.UpdateQueueLength(len(a.queue))
 		return nil
 	case <-ctx.Done():
+		a.untrack(item.ID)
 		a.metrics.IncrementDropped("ctx_done")
 		return ctx.Err()
 	case <-a.ctx.Done():
+		a.untrack(item.ID)
 		a.metrics.IncrementDropped("actor_closed")
-		return fmt.Errorf("actor is closed")
+		return ErrActorClosed
 	}
 }
 
 // Close gracefully shuts down the actor. It does not close the results channel
-// (caller-owned), but stops accepting and processing new work.
+// (caller-owned), but stops accepting and processing new work. Requests still
+// queued complete with StatusServiceUnavailable and an error wrapping
+// ErrActorClosed.
 func (a *LiveStatusActor) Close() {
 	a.closeOnce.Do(func() {
+		a.closed.Store(true)
 		if a.cancel != nil {
 			a.cancel()
 		}
-		a.closeConn()
+		// Workers close their own connections on the way out; unblock any
+		// request in progress so they notice promptly.
+		for _, c := range a.conns {
+			c.interrupt()
+		}
 		close(a.queue)
+		a.wg.Wait()
+		a.failQueued()
 	})
 	a.wg.Wait()
 }
 
-// processLoop runs the main processing loop.
-func (a *LiveStatusActor) processLoop() {
-	logger := a.logger.With("scope", "processLoop")
+// failQueued ends the items the stopped workers left in the queue, so nobody
+// waits for them forever: they get StatusServiceUnavailable and ErrActorClosed.
+func (a *LiveStatusActor) failQueued() {
+	for item := range a.queue {
+		if !a.untrack(item.ID) {
+			continue // cancelled while queued; its result is out
+		}
+		a.metrics.IncrementDropped("actor_closed")
+		err := fmt.Errorf("site %s: %w", a.siteName, ErrActorClosed)
+		if item.stream != nil {
+			item.stream.finish(err)
+			continue
+		}
+		a.publishNoWait(item, &Result{StatusCode: StatusServiceUnavailable, Error: err})
+	}
+	a.metrics.UpdateQueueLength(0)
+}
+
+// processLoop runs the processing loop of one worker and its connection.
+func (a *LiveStatusActor) processLoop(c *actorConn) {
+	logger := a.logger.With("scope", "processLoop", "conn", c.id)
 	logger.Debug("START")
 	defer logger.Debug("FINISHED")
 	defer a.wg.Done()
+	defer func() {
+		if c.conn != nil {
+			a.closeConn(c)
+		}
+		if c.pipe != nil {
+			// Requests still outstanding fail on the closed connection.
+			close(c.pipe.pending)
+		}
+	}()
 
 	// Dynamic health-check cadence: faster when not connected, slower when healthy
 	const (
@@ -220,15 +359,29 @@ func (a *LiveStatusActor) processLoop() {
 	// Use a timer so we can adapt the interval
 	timer := time.NewTimer(intervalDisconnected)
 	defer timer.Stop()
+	probing := false // the timer runs at the breaker's probe interval
 
 	for {
+		if !probing && a.breaker.current() != BreakerClosed {
+			// The breaker opened on a request; probe soon rather than at the
+			// regular cadence.
+			probing = true
+			timer.Reset(a.breaker.probeInterval())
+		}
 		select {
 		case <-a.ctx.Done():
 			return
+		case <-c.reconfigure:
+			a.applyConfig(c)
 		case <-timer.C:
-			a.runHealthCheck()
+			a.applyConfig(c)
+			a.settle(c)
+			a.runHealthCheck(c)
 			// Reset cadence based on current connectivity state
-			if ConnectivityState(a.connState.Load()) == StateConnected {
+			probing = a.breaker.current() != BreakerClosed
+			if probing {
+				timer.Reset(a.breaker.probeInterval())
+			} else if ConnectivityState(c.state.Load()) == StateConnected {
 				if !timer.Stop() { /* channel already drained by receive above */
 				}
 				timer.Reset(intervalConnected)
@@ -243,15 +396,31 @@ func (a *LiveStatusActor) processLoop() {
 			}
 
 			a.metrics.UpdateQueueLength(len(a.queue))
-			a.processItem(item)
+			a.applyConfig(c)
+			ctx, done, ok := a.begin(item)
+			if !ok {
+				continue // cancelled or expired while queued
+			}
+			if a.breaker.current() != BreakerClosed {
+				a.failFast(item)
+				done()
+				continue
+			}
+			if c.pipe != nil && c.cfg != nil && item.stream == nil && item.Command == nil {
+				a.pipelineItem(c, ctx, item, done)
+				continue
+			}
+			a.settle(c)
+			a.processItem(c, ctx, item)
+			done()
 		}
 	}
 }
 
-// processItem processes a single work item with metrics and panic safety.
-func (a *LiveStatusActor) processItem(item *WorkItem) {
+// processItem processes a single work item on c with metrics and panic safety.
+func (a *LiveStatusActor) processItem(c *actorConn, ctx context.Context, item *WorkItem) {
 	// Track in-flight
-	a.metrics.IncrementInFlight()
+	a.metrics.IncrementInFlightForConn(c.label)
 
 	// Start timing
 	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(duration float64) {
@@ -269,41 +438,51 @@ func (a *LiveStatusActor) processItem(item *WorkItem) {
 					"id", item.ID,
 				)
 				a.metrics.IncrementPanics()
-				a.publishResult(item.ID, &Result{StatusCode: 500, Error: fmt.Errorf("panic: %v", r)})
+				if item.stream != nil {
+					item.stream.finish(fmt.Errorf("panic: %v", r))
+					return
+				}
+				a.publishResult(item, &Result{StatusCode: 500, Error: fmt.Errorf("panic: %v", r)})
 			}
 		}()
 
-		a.processOne(item)
+		a.processOne(c, ctx, item)
 	}()
 
 	// Decrement in-flight
-	a.metrics.DecrementInFlight()
+	a.metrics.DecrementInFlightForConn(c.label)
 }
 
-// processOne implements the actual livestatus logic (simulated here).
-func (a *LiveStatusActor) processOne(item *WorkItem) {
+// processOne implements the actual livestatus logic (simulated here). ctx is
+// the item's execution context (see begin).
+func (a *LiveStatusActor) processOne(c *actorConn, ctx context.Context, item *WorkItem) {
+	if item.stream != nil {
+		a.processStream(c, ctx, item)
+		return
+	}
+
 	var result *Result
 
 	// If a real config is provided, execute the query against LiveStatus.
-	if a.config != nil {
-		// Single attempt; rely on health-check to maintain connectivity
-		conn, reader, err := ensureConn(a.ctx, a.config, a.conn)
-		if err != nil {
-			result = &Result{StatusCode: 500, Error: err}
-		} else {
-			a.conn, a.reader = conn, reader
-			res, err := execOverPersistentConn(a.logger, a.ctx, a.config, a.conn, a.reader, item.Query)
-			if err != nil {
-				result = &Result{StatusCode: 500, Error: err}
-			} else {
-				result = res
-			}
-		}
+	if c.cfg != nil {
+		// Cancellation has to unblock socket I/O; deadlines already reach the socket.
+		stop := context.AfterFunc(ctx, c.interrupt)
+		defer stop()
+		// Failed attempts are retried per the retry policy; the health check
+		// maintains connectivity in between requests.
+		result = a.execute(c, ctx, item)
 	} else {
 		// Fallback simulation (keeps tests fast without requiring a LiveStatus endpoint)
-		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			a.complete(item, abortedResult(context.Cause(ctx)))
+			return
+		}
 
 		switch {
+		case item.Command != nil:
+			result = &Result{StatusCode: StatusOK, Data: fmt.Appendf(nil, "Processed: %s", item.Command.Build())}
 		case string(item.Query.table) == "error":
 			result = &Result{StatusCode: StatusInternalServerError, Error: fmt.Errorf("simulated error")}
 		case string(item.Query.table) == "not_found":
@@ -313,6 +492,11 @@ func (a *LiveStatusActor) processOne(item *WorkItem) {
 		}
 	}
 
+	a.complete(item, result)
+}
+
+// complete records the outcome of an item in the metrics and publishes its result.
+func (a *LiveStatusActor) complete(item *WorkItem, result *Result) {
 	// Update metrics based on result: track numeric HTTP-like status code
 	statusCodeStr := strconv.Itoa(result.StatusCode)
 	a.metrics.IncrementProcessed(statusCodeStr)
@@ -323,85 +507,103 @@ func (a *LiveStatusActor) processOne(item *WorkItem) {
 	}
 
 	// Publish result (non-blocking)
-	a.publishResult(item.ID, result)
+	a.publishResult(item, result)
 }
 
-func (a *LiveStatusActor) closeConn() {
-	if a.conn != nil {
-		_ = a.conn.Close()
+func (a *LiveStatusActor) closeConn(c *actorConn) {
+	if c.conn != nil {
+		_ = c.conn.Close()
 	}
-	a.conn = nil
-	a.reader = nil
+	c.set(nil, nil)
 	// track closes
-	if !a.activeSince.IsZero() {
-		d := time.Since(a.activeSince).Seconds()
-		a.metrics.SetClientConnDuration(d)
-	}
-	a.activeSince = time.Time{}
-	a.metrics.SetClientConnected(0)
-	a.metrics.SetClientConnUptime(0)
-	a.emit(ConnectivityEvent{Actor: a.siteName, State: StateDisconnected, Time: time.Now(), Reason: "closed"})
+	if !c.activeSince.IsZero() {
+		d := time.Since(c.activeSince).Seconds()
+		a.metrics.SetClientConnDurationForConn(c.label, d)
+	}
+	c.activeSince = time.Time{}
+	a.metrics.SetClientConnectedForConn(c.label, 0)
+	a.metrics.SetClientConnUptimeForConn(c.label, 0)
+	a.emit(c, ConnectivityEvent{State: StateDisconnected, Time: time.Now(), Reason: "closed"})
 	a.metrics.IncrementReconnects("closed")
 }
 
-// runHealthCheck periodically ensures the connection is healthy in real mode.
-func (a *LiveStatusActor) runHealthCheck() {
-	// Only run health checks when there is no pending work
-	if len(a.queue) > 0 {
-		a.logger.Debug("health-check skipped: pending work", "pending", len(a.queue))
+// runHealthCheck periodically ensures the connection c is healthy in real mode.
+func (a *LiveStatusActor) runHealthCheck(c *actorConn) {
+	if a.breaker.current() != BreakerClosed {
+		// The check is the breaker's half-open probe; queued work is failing
+		// fast meanwhile, so it doesn't wait for the queue to drain.
+		if !a.breaker.probe() {
+			return // another worker is probing
+		}
+		a.breakerChanged(c, BreakerHalfOpen, nil)
+	} else if len(a.queue) > 0 {
+		// Only run health checks when there is no pending work
+		a.logger.Debug("health-check skipped: pending work", "pending", len(a.queue), "conn", c.id)
 		return
 	}
-	if a.config == nil {
+	if c.cfg == nil {
 		a.logger.Debug("Missing config")
 		return
 	}
-	a.logger.Debug("health-check tick", "actor", a.siteName)
+	logger := a.logger.With("conn", c.id)
+	logger.Debug("health-check tick", "actor", a.siteName)
 	query := NewLiveStatusQuery(Table("hosts")).Columns("name").Limit(1)
-	prevNil := (a.conn == nil)
-	a.logger.Debug("health-check ensureConn", "addr", a.config.Address, "prevNil", prevNil)
-	conn, reader, err := ensureConn(a.ctx, a.config, a.conn)
+	prevNil := (c.conn == nil)
+	logger.Debug("health-check ensureConn", "addr", c.cfg.Address, "prevNil", prevNil)
+	conn, reader, err := ensureConn(a.ctx, c.cfg, c.conn)
 	if err != nil {
-		a.logger.Warn("health-check connection failed", "err", err)
+		logger.Warn("health-check connection failed", "err", err)
 		a.metrics.IncrementReconnects("conn_error")
 		a.metrics.IncrementConnectionErrors()
-		a.emit(ConnectivityEvent{Actor: a.siteName, State: StateRetrying, Time: time.Now(), Reason: "conn_error", Err: err})
-		a.metrics.SetClientConnected(0)
-		a.closeConn()
+		a.emit(c, ConnectivityEvent{State: StateRetrying, Time: time.Now(), Reason: "conn_error", Err: err})
+		a.metrics.SetClientConnectedForConn(c.label, 0)
+		a.closeConn(c)
+		a.connFailed(c, err)
 		return
 	}
-	a.conn, a.reader = conn, reader
-	if prevNil && a.conn != nil {
-		a.logger.Debug("health-check connection established", "addr", a.config.Address)
+	c.set(conn, reader)
+	if prevNil && c.conn != nil {
+		logger.Debug("health-check connection established", "addr", c.cfg.Address)
 		a.metrics.IncrementReconnects("established")
 		a.metrics.IncrementConnectionDials()
-		a.metrics.SetClientConnected(1)
-		a.activeSince = time.Now()
-		a.emit(ConnectivityEvent{Actor: a.siteName, State: StateConnected, Time: a.activeSince, Reason: "established"})
+		a.metrics.SetClientConnectedForConn(c.label, 1)
+		c.activeSince = time.Now()
+		a.emit(c, ConnectivityEvent{State: StateConnected, Time: c.activeSince, Reason: "established"})
 	}
-	a.logger.Debug("health-check probe", "table", "hosts", "limit", 1)
+	logger.Debug("health-check probe", "table", "hosts", "limit", 1)
 	start := time.Now()
-	if _, err := execOverPersistentConn(a.logger, a.ctx, a.config, a.conn, a.reader, *query); err != nil {
-		a.logger.Warn("health-check query failed", "err", err, "duration", time.Since(start))
+	if _, err := execOverPersistentConn(a.logger, a.ctx, c.cfg, c.conn, c.reader, *query); err != nil {
+		logger.Warn("health-check query failed", "err", err, "duration", time.Since(start))
 		a.metrics.IncrementReconnects("probe_error")
 		a.metrics.IncrementConnectionErrors()
-		a.emit(ConnectivityEvent{Actor: a.siteName, State: StateRetrying, Time: time.Now(), Reason: "probe_error", Err: err})
-		a.closeConn()
+		a.emit(c, ConnectivityEvent{State: StateRetrying, Time: time.Now(), Reason: "probe_error", Err: err})
+		a.closeConn(c)
+		a.connFailed(c, err)
 	} else {
-		a.logger.Debug("health-check ok", "duration", time.Since(start))
-		if !a.activeSince.IsZero() {
-			a.metrics.SetClientConnUptime(time.Since(a.activeSince).Seconds())
+		logger.Debug("health-check ok", "duration", time.Since(start))
+		a.connOK(c)
+		if !c.activeSince.IsZero() {
+			a.metrics.SetClientConnUptimeForConn(c.label, time.Since(c.activeSince).Seconds())
 		}
 	}
-	a.logger.Debug("health-check response")
+	logger.Debug("health-check response")
 }
 
-// publishResult tries to deliver the result to the shared results bus without blocking.
-func (a *LiveStatusActor) publishResult(id RequestID, res *Result) {
-	env := ResultMsg{ID: id, Result: res}
-	select {
-	case a.results <- env:
-	default:
-		// Results bus full — drop on floor and count it.
-		a.metrics.IncrementDropped("result_chan_full")
+// publishResult hands the result to the item's Future, if it has one, or
+// delivers it to the shared results bus according to the delivery policy.
+func (a *LiveStatusActor) publishResult(item *WorkItem, res *Result) {
+	if item.future != nil {
+		item.future.resolve(res, nil)
+		return
+	}
+	a.deliver(ResultMsg{ID: item.ID, Result: res})
+}
+
+// publishNoWait is publishResult for callers that are not workers (see deliverNoWait).
+func (a *LiveStatusActor) publishNoWait(item *WorkItem, res *Result) {
+	if item.future != nil {
+		item.future.resolve(res, nil)
+		return
 	}
+	a.deliverNoWait(ResultMsg{ID: item.ID, Result: res})
 }
@@ -0,0 +1,182 @@
+package livestatus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the error of requests failed fast because their site's
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a site's circuit breaker. The values are those
+// of the circuit_state gauge.
+type BreakerState int32
+
+const (
+	// BreakerClosed lets requests through; this is the normal state.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests fast until a health check probe succeeds.
+	BreakerOpen
+	// BreakerHalfOpen is an open breaker whose probe is in progress.
+	BreakerHalfOpen
+)
+
+// String returns a human-friendly name for the breaker state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return fmt.Sprintf("unknown_breaker_state_%d", int(s))
+	}
+}
+
+// CircuitBreaker configures the actor's circuit breaker (see SetCircuitBreaker).
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive connection or health check
+	// failures that opens the breaker; 0 disables it.
+	FailureThreshold int
+	// ProbeInterval is how often the health check probes the site while the
+	// breaker is open (default 5s).
+	ProbeInterval time.Duration
+}
+
+// SetCircuitBreaker enables a circuit breaker for the actor's site. Once
+// FailureThreshold connection attempts or health checks in a row have failed,
+// the breaker opens: queued requests then fail right away with StatusServiceUnavailable
+// and an error wrapping ErrCircuitOpen, instead of each waiting out the connect
+// timeout, and retries stop. While open, the health check of one worker at a
+// time probes the site every ProbeInterval; the breaker is half-open during
+// the probe, which closes it on success and opens it again on failure. Any
+// response from the site resets the failure count.
+//
+// Transitions are published as ConnectivityEvents with Reason "circuit_open"
+// (StateDisconnected), "circuit_half_open" (StateRetrying) or "circuit_closed"
+// (StateConnected), and the circuit_state gauge follows the breaker state.
+// Call it before Start.
+func (a *LiveStatusActor) SetCircuitBreaker(cb CircuitBreaker) {
+	if cb.ProbeInterval <= 0 {
+		cb.ProbeInterval = 5 * time.Second
+	}
+	a.breaker.mu.Lock()
+	a.breaker.opts = cb
+	a.breaker.mu.Unlock()
+}
+
+// BreakerState returns the current state of the actor's circuit breaker.
+func (a *LiveStatusActor) BreakerState() BreakerState {
+	return a.breaker.current()
+}
+
+// circuit is the breaker state shared by all workers of an actor.
+type circuit struct {
+	mu       sync.Mutex
+	opts     CircuitBreaker
+	state    BreakerState
+	failures int // consecutive
+}
+
+func (b *circuit) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// probeInterval is the health check cadence while the breaker is not closed.
+func (b *circuit) probeInterval() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opts.ProbeInterval
+}
+
+// failure records a failed connection attempt or probe and returns the new
+// state if it changed.
+func (b *circuit) failure() (BreakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opts.FailureThreshold < 1 {
+		return b.state, false
+	}
+	b.failures++
+	switch {
+	case b.state == BreakerHalfOpen,
+		b.state == BreakerClosed && b.failures >= b.opts.FailureThreshold:
+		b.state = BreakerOpen
+		return b.state, true
+	}
+	return b.state, false
+}
+
+// success records a response from the site and returns the new state if it
+// changed.
+func (b *circuit) success() (BreakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if b.state == BreakerClosed {
+		return b.state, false
+	}
+	b.state = BreakerClosed
+	return b.state, true
+}
+
+// probe moves an open breaker to half-open. It reports whether the caller
+// should run the probe, which only one caller at a time gets to do.
+func (b *circuit) probe() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerOpen {
+		return false
+	}
+	b.state = BreakerHalfOpen
+	return true
+}
+
+// connFailed feeds a failed connection attempt or probe on c to the breaker.
+func (a *LiveStatusActor) connFailed(c *actorConn, err error) {
+	if state, changed := a.breaker.failure(); changed {
+		a.breakerChanged(c, state, err)
+	}
+}
+
+// connOK feeds a response from the site on c to the breaker.
+func (a *LiveStatusActor) connOK(c *actorConn) {
+	if state, changed := a.breaker.success(); changed {
+		a.breakerChanged(c, state, nil)
+	}
+}
+
+// breakerChanged reports a breaker transition caused by c.
+func (a *LiveStatusActor) breakerChanged(c *actorConn, state BreakerState, err error) {
+	a.metrics.SetCircuitState(int(state))
+	ev := ConnectivityEvent{Time: time.Now(), Reason: "circuit_" + state.String(), Err: err}
+	switch state {
+	case BreakerOpen:
+		a.logger.Warn("circuit breaker open", "conn", c.id, "err", err)
+		ev.State = StateDisconnected
+	case BreakerHalfOpen:
+		a.logger.Info("circuit breaker half-open, probing", "conn", c.id)
+		ev.State = StateRetrying
+	default:
+		a.logger.Info("circuit breaker closed", "conn", c.id)
+		ev.State = StateConnected
+	}
+	a.emit(c, ev)
+}
+
+// failFast fails item because the breaker is open.
+func (a *LiveStatusActor) failFast(item *WorkItem) {
+	err := fmt.Errorf("site %s: %w", a.siteName, ErrCircuitOpen)
+	if item.stream != nil {
+		item.stream.finish(err)
+		return
+	}
+	a.complete(item, &Result{StatusCode: StatusServiceUnavailable, Error: err})
+}
@@ -0,0 +1,122 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBreakerOpensAndFailsFast(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, events := startTestActor(t, "test_breaker", dialerConfig(flakyDialer(&dials, 100, 0)), nil, func(actor *LiveStatusActor) {
+		actor.SetCircuitBreaker(CircuitBreaker{FailureThreshold: 2, ProbeInterval: time.Minute})
+	})
+	q := *NewLiveStatusQuery(Table("hosts"))
+
+	for i := 0; i < 2; i++ {
+		res, err := actor.Query(context.Background(), q)
+		is.NoErr(err)
+		is.Equal(res.StatusCode, StatusInternalServerError) // connection refused
+		is.True(!errors.Is(res.Error, ErrCircuitOpen))
+	}
+	ev := recvEvent(t, events, "circuit_", time.Second)
+	is.Equal(ev.Reason, "circuit_open")
+	is.Equal(ev.State, StateDisconnected)
+	is.True(ev.Err != nil)
+	is.Equal(actor.BreakerState(), BreakerOpen)
+	is.Equal(testutil.ToFloat64(actor.metrics.circuitState.WithLabelValues("test_breaker")), 1.0)
+
+	start := time.Now()
+	res, err := actor.Query(context.Background(), q)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusServiceUnavailable)
+	is.True(errors.Is(res.Error, ErrCircuitOpen))
+	is.True(time.Since(start) < 40*time.Millisecond) // no dial
+	is.Equal(dials.Load(), int32(2))
+}
+
+func TestBreakerStopsRetries(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, _ := startTestActor(t, "test_breaker", dialerConfig(flakyDialer(&dials, 100, 0)), nil, func(actor *LiveStatusActor) {
+		actor.SetCircuitBreaker(CircuitBreaker{FailureThreshold: 2, ProbeInterval: time.Minute})
+		actor.SetRetryPolicy(*fastRetries())
+	})
+
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusInternalServerError)
+	is.Equal(dials.Load(), int32(2)) // the third attempt was not made
+	is.Equal(actor.BreakerState(), BreakerOpen)
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	// Two requests open the breaker, the first probe fails, the second succeeds.
+	actor, events := startTestActor(t, "test_breaker", dialerConfig(flakyDialer(&dials, 3, 0)), nil, func(actor *LiveStatusActor) {
+		actor.SetCircuitBreaker(CircuitBreaker{FailureThreshold: 2, ProbeInterval: 20 * time.Millisecond})
+	})
+	q := *NewLiveStatusQuery(Table("hosts"), "name")
+
+	for i := 0; i < 2; i++ {
+		_, err := actor.Query(context.Background(), q)
+		is.NoErr(err)
+	}
+	var got []string
+	for _, want := range []string{"circuit_open", "circuit_half_open", "circuit_open", "circuit_half_open", "circuit_closed"} {
+		ev := recvEvent(t, events, "circuit_", time.Second)
+		is.Equal(ev.Reason, want)
+		got = append(got, ev.State.String())
+	}
+	is.Equal(got, []string{"disconnected", "retrying", "disconnected", "retrying", "connected"})
+	is.Equal(actor.BreakerState(), BreakerClosed)
+	is.Equal(testutil.ToFloat64(actor.metrics.circuitState.WithLabelValues("test_breaker")), 0.0)
+
+	res, err := actor.Query(context.Background(), q)
+	is.NoErr(err)
+	is.NoErr(res.Error)
+	is.Equal(string(res.Data), `[["web01"]]`)
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	is := is.New(t)
+	var b circuit
+	b.opts = CircuitBreaker{FailureThreshold: 2}
+
+	_, changed := b.failure()
+	is.True(!changed)
+	_, changed = b.success()
+	is.True(!changed)
+	_, changed = b.failure()
+	is.True(!changed) // not consecutive
+	state, changed := b.failure()
+	is.True(changed)
+	is.Equal(state, BreakerOpen)
+
+	is.True(b.probe())
+	is.True(!b.probe()) // one probe at a time
+	state, changed = b.success()
+	is.True(changed)
+	is.Equal(state, BreakerClosed)
+}
+
+func TestBreakerDisabledByDefault(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, _ := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 100, 0)), nil, nil)
+
+	for i := 0; i < 10; i++ {
+		res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+		is.NoErr(err)
+		is.Equal(res.StatusCode, StatusInternalServerError)
+	}
+	is.Equal(dials.Load(), int32(10))
+	is.Equal(actor.BreakerState(), BreakerClosed)
+}
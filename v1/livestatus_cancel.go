@@ -0,0 +1,176 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCancelled is the cause of requests aborted with LiveStatusActor.Cancel.
+var ErrCancelled = errors.New("request cancelled")
+
+// ErrActorClosed is returned for requests submitted to a closed actor, and
+// wrapped in the Result of requests still queued when it was closed.
+var ErrActorClosed = errors.New("actor is closed")
+
+// request tracks a submitted work item until its result is published, so it
+// can be cancelled.
+type request struct {
+	item    *WorkItem
+	running bool
+	cancel  context.CancelCauseFunc // aborts the running request
+}
+
+// track registers an item that is about to be queued.
+func (a *LiveStatusActor) track(item *WorkItem) {
+	a.reqMu.Lock()
+	a.requests[item.ID] = &request{item: item}
+	a.reqMu.Unlock()
+}
+
+// untrack forgets an item that was not queued after all, or has finished. It
+// reports whether the item was still tracked.
+func (a *LiveStatusActor) untrack(id RequestID) bool {
+	a.reqMu.Lock()
+	defer a.reqMu.Unlock()
+	_, ok := a.requests[id]
+	delete(a.requests, id)
+	return ok
+}
+
+// Cancel aborts a queued or running request. A queued request is dropped and
+// its Result, with StatusClientClosedRequest and an error wrapping
+// ErrCancelled, is published right away; Cancel never waits for room on the
+// results bus, so under DeliverBlock that Result is dropped (and reported to
+// OnDrop) if the bus is full. A running request has its socket I/O
+// interrupted, which costs its connection; the worker then publishes the same
+// Result, unless the response had already been read, which is then published
+// as usual. Pipelined requests (see SetPipelineDepth) differ: they are still
+// read off the connection so the others keep their place, and report the
+// cancellation even if their response arrived in full.
+// Cancel returns false if the request is unknown or already finished.
+func (a *LiveStatusActor) Cancel(id RequestID) bool {
+	a.reqMu.Lock()
+	r, ok := a.requests[id]
+	if !ok {
+		a.reqMu.Unlock()
+		return false
+	}
+	if r.running {
+		r.cancel(ErrCancelled)
+		a.reqMu.Unlock()
+		return true
+	}
+	delete(a.requests, id)
+	a.reqMu.Unlock()
+
+	// The worker that dequeues it finds it untracked and skips it.
+	a.metrics.IncrementDropped("cancelled")
+	res := abortedResult(ErrCancelled)
+	if r.item.stream != nil {
+		r.item.stream.finish(res.Error)
+		return true
+	}
+	// This runs on the caller's goroutine, which may be the one reading the bus.
+	a.publishNoWait(r.item, res)
+	return true
+}
+
+// begin marks item as running and returns the context to execute it under,
+// and the function to call once its result is out. If the item was cancelled
+// or expired while queued, its result has been published and ok is false.
+func (a *LiveStatusActor) begin(item *WorkItem) (ctx context.Context, done func(), ok bool) {
+	a.reqMu.Lock()
+	r, ok := a.requests[item.ID]
+	if !ok {
+		a.reqMu.Unlock()
+		return nil, nil, false
+	}
+	ctx, cancel := a.itemContext(item)
+	if err := ctx.Err(); err != nil {
+		cause := context.Cause(ctx)
+		delete(a.requests, item.ID)
+		a.reqMu.Unlock()
+		cancel(nil)
+		if errors.Is(cause, context.DeadlineExceeded) {
+			a.metrics.IncrementDropped("expired")
+		} else {
+			a.metrics.IncrementDropped("cancelled")
+		}
+		a.abort(item, cause)
+		return nil, nil, false
+	}
+	r.running, r.cancel = true, cancel
+	a.reqMu.Unlock()
+	return ctx, func() {
+		a.untrack(item.ID)
+		cancel(nil)
+	}, true
+}
+
+// itemContext derives the execution context of item from the actor's: it ends
+// with the submitter's context, at the item's deadline, or when cancelled.
+func (a *LiveStatusActor) itemContext(item *WorkItem) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(a.ctx)
+	stop := func() bool { return false }
+	if item.ctx != nil {
+		parent := item.ctx
+		stop = context.AfterFunc(parent, func() { cancel(context.Cause(parent)) })
+	}
+	release := context.CancelFunc(func() {})
+	if dl, ok := item.deadline(); ok {
+		ctx, release = context.WithDeadline(ctx, dl)
+	}
+	return ctx, func(cause error) {
+		stop()
+		cancel(cause)
+		release()
+	}
+}
+
+// deadline returns the earlier of the item's Deadline and its context's.
+func (w *WorkItem) deadline() (time.Time, bool) {
+	dl := w.Deadline
+	if w.ctx != nil {
+		if cdl, ok := w.ctx.Deadline(); ok && (dl.IsZero() || cdl.Before(dl)) {
+			dl = cdl
+		}
+	}
+	return dl, !dl.IsZero()
+}
+
+// aborted returns why ctx ended, or nil if it hasn't. A passed deadline counts
+// even before ctx notices: the socket deadline, set to the same instant, may
+// fire first.
+func aborted(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if dl, ok := ctx.Deadline(); ok && !time.Now().Before(dl) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// abort ends an item that was not, or not fully, executed.
+func (a *LiveStatusActor) abort(item *WorkItem, cause error) {
+	res := abortedResult(cause)
+	if item.stream != nil {
+		item.stream.finish(res.Error)
+		return
+	}
+	a.publishResult(item, res)
+}
+
+// abortedResult reports a request that ended because its context did:
+// StatusRequestTimeout for a passed deadline, StatusClientClosedRequest otherwise.
+func abortedResult(cause error) *Result {
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return &Result{StatusCode: StatusRequestTimeout, Error: fmt.Errorf("request deadline exceeded: %w", cause)}
+	}
+	if errors.Is(cause, ErrCancelled) {
+		return &Result{StatusCode: StatusClientClosedRequest, Error: cause}
+	}
+	return &Result{StatusCode: StatusClientClosedRequest, Error: fmt.Errorf("%w: %w", ErrCancelled, cause)}
+}
@@ -0,0 +1,220 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// blockingLivestatus answers "GET slow" only once release is closed, and
+// signals each slow request on got.
+func blockingLivestatus(t *testing.T) (addr string, got <-chan struct{}, release chan struct{}) {
+	t.Helper()
+	gotc := make(chan struct{}, 4)
+	release = make(chan struct{})
+	addr, _ = startFakeLivestatus(t, func(req string) (int, string) {
+		if strings.HasPrefix(req, "GET slow") {
+			gotc <- struct{}{}
+			<-release
+		}
+		return StatusOK, `[["web01"]]`
+	})
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+	return addr, gotc, release
+}
+
+// newTestActor builds an actor for site that logs errors only and publishes
+// its connectivity events on the returned channel. It is closed when the test
+// ends but not started.
+func newTestActor(t *testing.T, site string, config *LiveStatusConfig, results chan ResultMsg) (*LiveStatusActor, chan ConnectivityEvent) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, site, config, 16, results, prometheus.NewRegistry())
+	events := make(chan ConnectivityEvent, 32)
+	actor.SetEventChan(events)
+	t.Cleanup(actor.Close)
+	return actor, events
+}
+
+// startTestActor is newTestActor followed by setup, if any, and Start.
+func startTestActor(t *testing.T, site string, config *LiveStatusConfig, results chan ResultMsg, setup func(*LiveStatusActor)) (*LiveStatusActor, chan ConnectivityEvent) {
+	t.Helper()
+	actor, events := newTestActor(t, site, config, results)
+	if setup != nil {
+		setup(actor)
+	}
+	if err := actor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return actor, events
+}
+
+// recvEvent returns the next event on events whose Reason starts with reason.
+func recvEvent(t *testing.T, events <-chan ConnectivityEvent, reason string, timeout time.Duration) ConnectivityEvent {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev := <-events:
+			if strings.HasPrefix(ev.Reason, reason) {
+				return ev
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for a %q event", reason)
+			return ConnectivityEvent{}
+		}
+	}
+}
+
+func TestCancelQueuedRequest(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg, 4)
+	actor, _ := newTestActor(t, "test_cancel", nil, results)
+
+	// Not started yet, so the request stays queued.
+	id, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.True(actor.Cancel(id))
+	is.True(!actor.Cancel(id)) // already gone
+
+	msg := recvResult(t, results, time.Second)
+	is.Equal(msg.ID, id)
+	is.Equal(msg.Result.StatusCode, StatusClientClosedRequest)
+	is.True(errors.Is(msg.Result.Error, ErrCancelled))
+
+	// The worker skips it: the next result belongs to the next request.
+	is.NoErr(actor.Start(context.Background()))
+	next, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	msg = recvResult(t, results, time.Second)
+	is.Equal(msg.ID, next)
+	is.Equal(msg.Result.StatusCode, StatusOK)
+	is.Equal(testutil.ToFloat64(actor.metrics.droppedTotal.WithLabelValues("test_cancel", "cancelled")), 1.0)
+}
+
+func TestExpiredRequestIsSkipped(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg, 4)
+	actor, _ := newTestActor(t, "test_cancel", nil, results)
+
+	item := NewWorkItemFromQuery(nextRequestID(), NewLiveStatusQuery(Table("hosts")))
+	item.Deadline = time.Now().Add(20 * time.Millisecond)
+	is.NoErr(actor.Enqueue(context.Background(), item))
+	time.Sleep(50 * time.Millisecond)
+	is.NoErr(actor.Start(context.Background()))
+
+	msg := recvResult(t, results, time.Second)
+	is.Equal(msg.ID, item.ID)
+	is.Equal(msg.Result.StatusCode, StatusRequestTimeout)
+	is.True(errors.Is(msg.Result.Error, context.DeadlineExceeded))
+	is.Equal(testutil.ToFloat64(actor.metrics.droppedTotal.WithLabelValues("test_cancel", "expired")), 1.0)
+	is.True(!actor.Cancel(item.ID))
+}
+
+func TestCancelInFlightRequest(t *testing.T) {
+	is := is.New(t)
+	addr, got, _ := blockingLivestatus(t)
+	results := make(chan ResultMsg, 4)
+	actor, _ := newTestActor(t, "test_cancel", NewLiveStatusConfig(addr), results)
+	is.NoErr(actor.Start(context.Background()))
+
+	id, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("slow")))
+	is.NoErr(err)
+	<-got
+	start := time.Now()
+	is.True(actor.Cancel(id))
+
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.Equal(msg.Result.StatusCode, StatusClientClosedRequest)
+	is.True(errors.Is(msg.Result.Error, ErrCancelled))
+	is.True(time.Since(start) < time.Second)
+
+	// The worker is free again; the aborted connection is replaced.
+	id, err = actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	msg = recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.NoErr(msg.Result.Error)
+}
+
+func TestCallerDeadlineReachesSocket(t *testing.T) {
+	is := is.New(t)
+	addr, got, _ := blockingLivestatus(t)
+	results := make(chan ResultMsg, 4)
+	config := NewLiveStatusConfig(addr) // ReadTimeout 30s
+	actor, _ := newTestActor(t, "test_cancel", config, results)
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("slow")))
+	is.NoErr(err)
+	<-got
+
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.Equal(msg.Result.StatusCode, StatusRequestTimeout)
+	is.True(time.Since(start) < time.Second)
+}
+
+func TestCallerCancelAbortsRequest(t *testing.T) {
+	is := is.New(t)
+	addr, got, _ := blockingLivestatus(t)
+	results := make(chan ResultMsg, 4)
+	actor, _ := newTestActor(t, "test_cancel", NewLiveStatusConfig(addr), results)
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("slow")))
+	is.NoErr(err)
+	<-got
+	cancel()
+
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.Equal(msg.Result.StatusCode, StatusClientClosedRequest)
+	is.True(errors.Is(msg.Result.Error, context.Canceled))
+}
+
+func TestCancelPipelinedRequest(t *testing.T) {
+	is := is.New(t)
+	addr, got, release := blockingLivestatus(t)
+	results := make(chan ResultMsg, 4)
+	actor, _ := newTestActor(t, "test_cancel", NewLiveStatusConfig(addr), results)
+	actor.SetPipelineDepth(4)
+	is.NoErr(actor.Start(context.Background()))
+
+	slow, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("slow")))
+	is.NoErr(err)
+	<-got
+	fast, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	time.Sleep(20 * time.Millisecond) // let the second request get written
+	is.True(actor.Cancel(slow))
+	close(release)
+
+	// The cancelled response is still consumed, so the next one lines up.
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, slow)
+	is.Equal(msg.Result.StatusCode, StatusClientClosedRequest)
+	msg = recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, fast)
+	is.NoErr(msg.Result.Error)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+}
@@ -0,0 +1,239 @@
+package livestatus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeliveryPolicy decides what happens to a result when the results bus is full.
+type DeliveryPolicy int
+
+const (
+	// DeliverDropNewest drops the result that doesn't fit. This is the default.
+	DeliverDropNewest DeliveryPolicy = iota
+	// DeliverBlock makes the worker wait up to DeliveryOptions.Timeout for room,
+	// then drops the result. Waiting stalls the worker's connection.
+	DeliverBlock
+	// DeliverDropOldest queues results in an internal ring of
+	// DeliveryOptions.RingSize that is forwarded to the bus as it drains; when
+	// the ring is full, its oldest result is dropped to make room.
+	DeliverDropOldest
+	// DeliverDeadLetter hands results that don't fit to DeliveryOptions.DeadLetter.
+	DeliverDeadLetter
+)
+
+// String returns the policy name.
+func (p DeliveryPolicy) String() string {
+	switch p {
+	case DeliverDropNewest:
+		return "drop_newest"
+	case DeliverBlock:
+		return "block"
+	case DeliverDropOldest:
+		return "drop_oldest"
+	case DeliverDeadLetter:
+		return "dead_letter"
+	default:
+		return fmt.Sprintf("unknown_policy_%d", int(p))
+	}
+}
+
+// DeliveryOptions configures how results reach the results bus. Results of
+// Submit and Query don't use the bus and are never dropped.
+type DeliveryOptions struct {
+	Policy DeliveryPolicy
+	// Timeout bounds the wait of DeliverBlock; zero waits until the actor closes.
+	Timeout time.Duration
+	// RingSize is the capacity of the DeliverDropOldest ring (default 100).
+	RingSize int
+	// DeadLetter receives the results DeliverDeadLetter can't put on the bus.
+	DeadLetter func(ResultMsg)
+	// OnDrop, if set, is called with every result that is dropped and the
+	// reason, the same as the dropped_total label: result_chan_full (bus full),
+	// timeout (DeliverBlock), ring_full (DeliverDropOldest), no_result_chan or
+	// actor_closed.
+	OnDrop func(msg ResultMsg, reason string)
+}
+
+// SetDelivery sets the delivery policy for the results bus. Callbacks run on
+// worker goroutines, or on the goroutine calling Cancel or Close, and should
+// return quickly. Call it before Start.
+func (a *LiveStatusActor) SetDelivery(opts DeliveryOptions) error {
+	switch opts.Policy {
+	case DeliverDropNewest, DeliverBlock:
+	case DeliverDropOldest:
+		if opts.RingSize <= 0 {
+			opts.RingSize = 100
+		}
+	case DeliverDeadLetter:
+		if opts.DeadLetter == nil {
+			return fmt.Errorf("dead-letter delivery needs a DeadLetter callback")
+		}
+	default:
+		return fmt.Errorf("unknown delivery policy %d", int(opts.Policy))
+	}
+	if opts.Timeout < 0 {
+		return fmt.Errorf("negative delivery timeout %s", opts.Timeout)
+	}
+	a.delivery = opts
+	return nil
+}
+
+// deliver puts msg on the results bus according to the delivery policy.
+func (a *LiveStatusActor) deliver(msg ResultMsg) {
+	if a.results == nil {
+		a.drop(msg, "no_result_chan")
+		return
+	}
+	switch a.delivery.Policy {
+	case DeliverBlock:
+		a.deliverBlocking(msg)
+		return
+	case DeliverDropOldest:
+		if a.ring != nil && a.ring.push(a, msg) {
+			return
+		}
+		// The forwarder is gone; fall through to a last direct attempt.
+	}
+	select {
+	case a.results <- msg:
+	default:
+		if a.delivery.Policy == DeliverDeadLetter {
+			a.metrics.IncrementDropped("dead_letter")
+			a.delivery.DeadLetter(msg)
+			return
+		}
+		// Results bus full — drop on floor and count it.
+		a.drop(msg, "result_chan_full")
+	}
+}
+
+func (a *LiveStatusActor) deliverBlocking(msg ResultMsg) {
+	var timeout <-chan time.Time
+	if a.delivery.Timeout > 0 {
+		t := time.NewTimer(a.delivery.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	var closed <-chan struct{}
+	if a.ctx != nil {
+		closed = a.ctx.Done()
+	}
+	select {
+	case a.results <- msg:
+	case <-timeout:
+		a.drop(msg, "timeout")
+	case <-closed:
+		a.drop(msg, "actor_closed")
+	}
+}
+
+// deliverNoWait is deliver for callers that are not workers, such as Cancel
+// and Close, which must not stall: under DeliverBlock a result that doesn't fit
+// is dropped at once. The other policies never wait anyway.
+func (a *LiveStatusActor) deliverNoWait(msg ResultMsg) {
+	if a.delivery.Policy != DeliverBlock || a.results == nil {
+		a.deliver(msg)
+		return
+	}
+	select {
+	case a.results <- msg:
+	default:
+		a.drop(msg, "result_chan_full")
+	}
+}
+
+// drop counts and reports a result that will never reach the bus.
+func (a *LiveStatusActor) drop(msg ResultMsg, reason string) {
+	a.metrics.IncrementDropped(reason)
+	a.logger.Warn("result dropped", "id", msg.ID, "reason", reason)
+	if a.delivery.OnDrop != nil {
+		a.delivery.OnDrop(msg, reason)
+	}
+}
+
+// resultRing buffers results for DeliverDropOldest.
+type resultRing struct {
+	mu     sync.Mutex
+	buf    []ResultMsg // oldest first
+	size   int
+	closed bool
+	ready  chan struct{} // signalled when buf becomes non-empty
+}
+
+func newResultRing(size int) *resultRing {
+	return &resultRing{size: size, ready: make(chan struct{}, 1)}
+}
+
+// push appends msg, evicting the oldest result if the ring is full. It returns
+// false once the forwarder has stopped.
+func (r *resultRing) push(a *LiveStatusActor, msg ResultMsg) bool {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return false
+	}
+	var evicted ResultMsg
+	full := len(r.buf) == r.size
+	if full {
+		evicted = r.buf[0]
+		r.buf = r.buf[1:]
+	}
+	r.buf = append(r.buf, msg)
+	r.mu.Unlock()
+
+	select {
+	case r.ready <- struct{}{}:
+	default:
+	}
+	if full {
+		a.drop(evicted, "ring_full")
+	}
+	return true
+}
+
+// pop removes the oldest result.
+func (r *resultRing) pop() (ResultMsg, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf) == 0 {
+		return ResultMsg{}, false
+	}
+	msg := r.buf[0]
+	r.buf = r.buf[1:]
+	return msg, true
+}
+
+// forwardResults moves results from the ring to the bus until the actor
+// closes; whatever is left then is dropped.
+func (a *LiveStatusActor) forwardResults() {
+	defer a.wg.Done()
+	defer func() {
+		a.ring.mu.Lock()
+		a.ring.closed = true
+		left := a.ring.buf
+		a.ring.buf = nil
+		a.ring.mu.Unlock()
+		for _, msg := range left {
+			a.drop(msg, "actor_closed")
+		}
+	}()
+	for {
+		msg, ok := a.ring.pop()
+		if !ok {
+			select {
+			case <-a.ring.ready:
+				continue
+			case <-a.ctx.Done():
+				return
+			}
+		}
+		select {
+		case a.results <- msg:
+		case <-a.ctx.Done():
+			a.drop(msg, "actor_closed")
+			return
+		}
+	}
+}
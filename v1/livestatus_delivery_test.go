@@ -0,0 +1,150 @@
+package livestatus
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type droppedResult struct {
+	id     RequestID
+	reason string
+}
+
+// recordDrops returns opts set to report dropped results on the returned
+// channel.
+func recordDrops(opts DeliveryOptions) (DeliveryOptions, chan droppedResult) {
+	drops := make(chan droppedResult, 16)
+	opts.OnDrop = func(msg ResultMsg, reason string) {
+		drops <- droppedResult{msg.ID, reason}
+	}
+	return opts, drops
+}
+
+func recvDrop(t *testing.T, drops chan droppedResult) droppedResult {
+	t.Helper()
+	select {
+	case d := <-drops:
+		return d
+	case <-time.After(2 * time.Second):
+		t.Fatal("no drop reported")
+		return droppedResult{}
+	}
+}
+
+func sendHosts(t *testing.T, actor *LiveStatusActor) RequestID {
+	t.Helper()
+	id, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestDeliveryDropNewestReportsID(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg, 1)
+	opts, drops := recordDrops(DeliveryOptions{})
+	actor, _ := startTestActor(t, "test_delivery", nil, results, func(actor *LiveStatusActor) { is.NoErr(actor.SetDelivery(opts)) })
+
+	first := sendHosts(t, actor)
+	second := sendHosts(t, actor)
+	is.Equal(recvDrop(t, drops), droppedResult{second, "result_chan_full"})
+	is.Equal(recvResult(t, results, time.Second).ID, first)
+	is.Equal(testutil.ToFloat64(actor.metrics.droppedTotal.WithLabelValues("test_delivery", "result_chan_full")), 1.0)
+}
+
+func TestDeliveryBlock(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg)
+	opts, drops := recordDrops(DeliveryOptions{Policy: DeliverBlock, Timeout: 200 * time.Millisecond})
+	actor, _ := startTestActor(t, "test_delivery", nil, results, func(actor *LiveStatusActor) { is.NoErr(actor.SetDelivery(opts)) })
+
+	// A late reader still gets the result.
+	id := sendHosts(t, actor)
+	time.Sleep(100 * time.Millisecond)
+	is.Equal(recvResult(t, results, time.Second).ID, id)
+
+	// Nobody reads: dropped once the timeout passes.
+	id = sendHosts(t, actor)
+	is.Equal(recvDrop(t, drops), droppedResult{id, "timeout"})
+}
+
+func TestDeliveryBlockCancelDoesNotWait(t *testing.T) {
+	is := is.New(t)
+	drops := make(chan droppedResult, 1)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_delivery", nil, 4, make(chan ResultMsg), prometheus.NewRegistry())
+	is.NoErr(actor.SetDelivery(DeliveryOptions{
+		Policy: DeliverBlock, // no Timeout, and not started: a worker would wait until Close
+		OnDrop: func(msg ResultMsg, reason string) { drops <- droppedResult{msg.ID, reason} },
+	}))
+	defer actor.Close()
+
+	id := sendHosts(t, actor)
+	done := make(chan bool)
+	go func() { done <- actor.Cancel(id) }()
+	select {
+	case ok := <-done:
+		is.True(ok)
+	case <-time.After(time.Second):
+		t.Fatal("Cancel blocked on the full bus")
+	}
+	is.Equal(recvDrop(t, drops), droppedResult{id, "result_chan_full"})
+}
+
+func TestDeliveryDropOldest(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg)
+	opts, drops := recordDrops(DeliveryOptions{Policy: DeliverDropOldest, RingSize: 2})
+	actor, _ := startTestActor(t, "test_delivery", nil, results, func(actor *LiveStatusActor) { is.NoErr(actor.SetDelivery(opts)) })
+
+	// The forwarder holds the first result; the ring takes two more, and the
+	// fourth evicts the oldest of those.
+	ids := []RequestID{sendHosts(t, actor), sendHosts(t, actor), sendHosts(t, actor), sendHosts(t, actor)}
+	is.Equal(recvDrop(t, drops), droppedResult{ids[1], "ring_full"})
+	for _, want := range []RequestID{ids[0], ids[2], ids[3]} {
+		is.Equal(recvResult(t, results, time.Second).ID, want)
+	}
+}
+
+func TestDeliveryDeadLetter(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg)
+	dead := make(chan ResultMsg, 1)
+	opts, drops := recordDrops(DeliveryOptions{
+		Policy:     DeliverDeadLetter,
+		DeadLetter: func(msg ResultMsg) { dead <- msg },
+	})
+	actor, _ := startTestActor(t, "test_delivery", nil, results, func(actor *LiveStatusActor) { is.NoErr(actor.SetDelivery(opts)) })
+
+	id := sendHosts(t, actor)
+	select {
+	case msg := <-dead:
+		is.Equal(msg.ID, id)
+		is.Equal(msg.Result.StatusCode, StatusOK)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no dead letter")
+	}
+	is.Equal(len(drops), 0) // handed off, not lost
+}
+
+func TestSetDeliveryValidation(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.DiscardHandler)
+	actor := NewLiveStatusActor(logger, "test_delivery", nil, 1, nil, prometheus.NewRegistry())
+	defer actor.Close()
+
+	is.True(actor.SetDelivery(DeliveryOptions{Policy: DeliverDeadLetter}) != nil)
+	is.True(actor.SetDelivery(DeliveryOptions{Policy: DeliveryPolicy(42)}) != nil)
+	is.True(actor.SetDelivery(DeliveryOptions{Policy: DeliverBlock, Timeout: -time.Second}) != nil)
+	is.NoErr(actor.SetDelivery(DeliveryOptions{Policy: DeliverDropOldest}))
+	is.Equal(actor.delivery.RingSize, 100)
+	is.Equal(DeliverDropOldest.String(), "drop_oldest")
+}
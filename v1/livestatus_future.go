@@ -0,0 +1,80 @@
+package livestatus
+
+import (
+	"context"
+	"sync"
+)
+
+// Future is the pending outcome of a request submitted with Submit. Its result
+// is delivered to the Future alone, never to the results bus, so it cannot be
+// dropped when the bus is full.
+type Future struct {
+	id   RequestID
+	once sync.Once
+	done chan struct{}
+	res  *Result
+	err  error
+}
+
+func newFuture(id RequestID) *Future {
+	return &Future{id: id, done: make(chan struct{})}
+}
+
+// ID returns the request ID, e.g. for Cancel.
+func (f *Future) ID() RequestID { return f.id }
+
+// Done is closed once the outcome is available.
+func (f *Future) Done() <-chan struct{} { return f.done }
+
+// Wait blocks until the outcome is available or ctx ends. The error reports a
+// request that could not be enqueued, or ctx ending; errors returned by
+// Livestatus are in Result.Error like on the results bus. Giving up on ctx
+// does not cancel the request (see Cancel).
+func (f *Future) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-f.done:
+		return f.res, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve sets the outcome. Only the first call has an effect.
+func (f *Future) resolve(res *Result, err error) {
+	f.once.Do(func() {
+		f.res, f.err = res, err
+		close(f.done)
+	})
+}
+
+// Submit enqueues a query, blocking while the queue is full, and returns a
+// Future for its result. If the query can't be enqueued (invalid query, actor
+// closed), the Future completes at once with the error.
+func (a *LiveStatusActor) Submit(query LiveStatusQuery) *Future {
+	return a.submit(context.Background(), query)
+}
+
+// Query executes a query and waits for its result. ctx bounds both the
+// request (see Enqueue) and the wait. Errors returned by Livestatus and
+// transport failures are reported in Result.Error; err is for requests that
+// could not be enqueued or were aborted, by ctx ending or by Cancel. An
+// aborted request still comes with its Result (StatusRequestTimeout or
+// StatusClientClosedRequest) unless the wait itself gave up.
+func (a *LiveStatusActor) Query(ctx context.Context, query LiveStatusQuery) (*Result, error) {
+	res, err := a.submit(ctx, query).Wait(ctx)
+	if err == nil && (res.StatusCode == StatusRequestTimeout || res.StatusCode == StatusClientClosedRequest) {
+		// Only the actor sets these, for aborts, possibly noticed by the
+		// worker before ctx.
+		return res, res.Error
+	}
+	return res, err
+}
+
+func (a *LiveStatusActor) submit(ctx context.Context, query LiveStatusQuery) *Future {
+	item := NewWorkItemFromQuery(nextRequestID(), &query)
+	item.future = newFuture(item.ID)
+	if err := a.Enqueue(ctx, item); err != nil {
+		item.future.resolve(nil, err)
+	}
+	return item.future
+}
@@ -0,0 +1,179 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryReturnsOwnResult(t *testing.T) {
+	is := is.New(t)
+	actor, _ := startTestActor(t, "test_future", nil, nil, func(actor *LiveStatusActor) { actor.SetPoolSize(3) }) // no results bus at all
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			table := fmt.Sprintf("table%d", i)
+			res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table(table)))
+			is.NoErr(err)
+			is.Equal(string(res.Data), "Processed: GET "+table+"\n") // each caller gets its own result
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSubmitBypassesFullResultsBus(t *testing.T) {
+	is := is.New(t)
+	results := make(chan ResultMsg) // nobody reads it
+	actor, _ := startTestActor(t, "test_future", nil, results, func(actor *LiveStatusActor) { actor.SetPoolSize(3) })
+
+	_, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	f := actor.Submit(*NewLiveStatusQuery(Table("services")))
+	is.True(f.ID() != 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	res, err := f.Wait(ctx)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+	is.Equal(string(res.Data), "Processed: GET services\n")
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("Done not closed after Wait returned")
+	}
+
+	// The bus result was dropped, the Future's was not.
+	time.Sleep(100 * time.Millisecond)
+	is.Equal(testutil.ToFloat64(actor.metrics.droppedTotal.WithLabelValues("test_future", "result_chan_full")), 1.0)
+}
+
+func TestSubmitEnqueueError(t *testing.T) {
+	is := is.New(t)
+	actor, _ := startTestActor(t, "test_future", nil, nil, func(actor *LiveStatusActor) { actor.SetPoolSize(3) })
+	actor.SetSchema(testSchema(t))
+
+	f := actor.Submit(*NewLiveStatusQuery("hosts", "nmae"))
+	res, err := f.Wait(context.Background())
+	is.True(errors.Is(err, ErrInvalidQuery))
+	is.True(res == nil)
+
+	actor.Close()
+	_, err = actor.Query(context.Background(), *NewLiveStatusQuery("hosts", "name"))
+	is.True(err != nil) // actor closed
+}
+
+func TestQueryContextEnds(t *testing.T) {
+	is := is.New(t)
+	actor, _ := startTestActor(t, "test_future", nil, nil, func(actor *LiveStatusActor) { actor.SetPoolSize(3) })
+
+	// The simulated request takes 50ms.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := actor.Query(ctx, *NewLiveStatusQuery(Table("hosts")))
+	is.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestQueryConnectTimeoutIsAResult(t *testing.T) {
+	is := is.New(t)
+	cfg := NewLiveStatusConfig("ignored")
+	cfg.ConnectTimeout = 20 * time.Millisecond
+	cfg.Dialer = DialerFunc(func(ctx context.Context) (net.Conn, error) {
+		<-ctx.Done() // a site that never answers the dial
+		return nil, ctx.Err()
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_future", cfg, 4, nil, prometheus.NewRegistry())
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	// The dial error wraps context.DeadlineExceeded, but the request wasn't aborted.
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusInternalServerError)
+	is.True(errors.Is(res.Error, context.DeadlineExceeded))
+}
+
+func TestQueryCancelled(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_future", nil, 4, nil, prometheus.NewRegistry())
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+		errc <- err
+	}()
+	var id RequestID
+	for deadline := time.Now().Add(time.Second); id == 0 && time.Now().Before(deadline); {
+		actor.reqMu.Lock()
+		for k := range actor.requests {
+			id = k
+		}
+		actor.reqMu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	is.True(actor.Cancel(id))
+	is.True(errors.Is(<-errc, ErrCancelled))
+}
+
+func TestCancelFuture(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_future", nil, 4, nil, prometheus.NewRegistry())
+	defer actor.Close()
+
+	f := actor.Submit(*NewLiveStatusQuery(Table("hosts"))) // queued until Start
+	is.True(actor.Cancel(f.ID()))
+	res, err := f.Wait(context.Background())
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusClientClosedRequest)
+}
+
+func TestCloseCompletesQueuedFutures(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_future", nil, 16, nil, prometheus.NewRegistry())
+	is.NoErr(actor.Start(context.Background()))
+
+	var futures []*Future
+	for range 10 {
+		futures = append(futures, actor.Submit(*NewLiveStatusQuery(Table("hosts"))))
+	}
+	actor.Close() // the single worker is busy with the first for 50ms
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, f := range futures {
+		res, err := f.Wait(ctx)
+		is.NoErr(err) // every Future completes
+		is.True(res.Error != nil)
+	}
+	actor.reqMu.Lock()
+	is.Equal(len(actor.requests), 0)
+	actor.reqMu.Unlock()
+
+	// Without workers, nothing else gets to the queued items first.
+	idle := NewLiveStatusActor(logger, "test_future", nil, 16, nil, prometheus.NewRegistry())
+	f := idle.Submit(*NewLiveStatusQuery(Table("hosts")))
+	idle.Close()
+	res, err := f.Wait(ctx)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusServiceUnavailable)
+	is.True(errors.Is(res.Error, ErrActorClosed))
+}
@@ -0,0 +1,367 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SiteColumn is the name of the column MultiSite prepends to every merged row.
+const SiteColumn = "site"
+
+// ErrNoSites is returned by MultiSite.Query when the selection matches no site.
+var ErrNoSites = errors.New("no sites selected")
+
+// Site is a member of a MultiSite. Queries go through Actor if set, which must
+// have been started, and otherwise connect directly with Config (see QueryOneOff).
+type Site struct {
+	Name    string
+	Labels  map[string]string
+	Actor   *LiveStatusActor
+	Config  *LiveStatusConfig
+	Timeout time.Duration // bounds each query on the site; 0 uses the MultiSite default
+	// AuthUser, when set, is sent as AuthUser with every query on the site.
+	AuthUser string
+}
+
+// SiteSelector picks the sites a query is sent to: those named in Names, or all
+// if Names is empty, that also carry every label in Labels.
+type SiteSelector struct {
+	Names  []string
+	Labels map[string]string
+}
+
+func (s SiteSelector) matches(site *Site) bool {
+	if len(s.Names) > 0 && !slices.Contains(s.Names, site.Name) {
+		return false
+	}
+	for k, v := range s.Labels {
+		if site.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// MultiSite sends the same query to several sites at once and merges their
+// rows, prefixing each with the name of the site it came from. It is safe for
+// concurrent use.
+type MultiSite struct {
+	mu      sync.RWMutex
+	sites   map[string]*Site
+	timeout time.Duration
+}
+
+// NewMultiSite returns a MultiSite of the given sites with a default per-site
+// timeout of 30s.
+func NewMultiSite(sites ...Site) (*MultiSite, error) {
+	m := &MultiSite{sites: make(map[string]*Site), timeout: 30 * time.Second}
+	for _, s := range sites {
+		if err := m.Add(s); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// SetTimeout sets the per-site timeout for sites without their own.
+func (m *MultiSite) SetTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeout = d
+}
+
+// Add adds a site. Its name must be unique and it needs an Actor or a Config.
+func (m *MultiSite) Add(s Site) error {
+	if s.Name == "" {
+		return fmt.Errorf("site name cannot be empty")
+	}
+	if s.Actor == nil && s.Config == nil {
+		return fmt.Errorf("site %q: needs an Actor or a Config", s.Name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sites[s.Name]; ok {
+		return fmt.Errorf("site %q already exists", s.Name)
+	}
+	s.Labels = maps.Clone(s.Labels)
+	m.sites[s.Name] = &s
+	return nil
+}
+
+// put adds s or replaces the site of the same name.
+func (m *MultiSite) put(s Site) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.Labels = maps.Clone(s.Labels)
+	m.sites[s.Name] = &s
+}
+
+// Remove removes the named site and reports whether it existed. Its actor, if
+// any, is left running.
+func (m *MultiSite) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sites[name]
+	delete(m.sites, name)
+	return ok
+}
+
+// Site returns a copy of the named site.
+func (m *MultiSite) Site(name string) (Site, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sites[name]
+	if !ok {
+		return Site{}, false
+	}
+	c := *s
+	c.Labels = maps.Clone(s.Labels)
+	return c, true
+}
+
+// Sites returns the names of all sites in sorted order.
+func (m *MultiSite) Sites() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.sites))
+	for name := range m.sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes the actors of all sites.
+func (m *MultiSite) Close() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, s := range m.sites {
+		if s.Actor != nil {
+			s.Actor.Close()
+		}
+	}
+}
+
+// SiteResult is the outcome of a MultiSite query on one site.
+type SiteResult struct {
+	Site       string
+	StatusCode int // 0 if no response was received
+	Rows       int
+	Duration   time.Duration
+	Err        error
+}
+
+// MultiResult holds the merged rows of a MultiSite query.
+type MultiResult struct {
+	// Columns is SiteColumn followed by the query's columns; with no Columns
+	// in the query they come from the sites' header rows.
+	Columns []string
+	// Rows are the rows of all successful sites, by site name, each with the
+	// site name as its first cell. Header rows are removed.
+	Rows []Row
+	// Sites has the outcome for every selected site, by name.
+	Sites map[string]*SiteResult
+
+	dec *Decoder
+}
+
+// Failed returns the names of the sites whose query failed, in sorted order.
+func (r *MultiResult) Failed() []string {
+	var names []string
+	for name, sr := range r.Sites {
+		if sr.Err != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Unmarshal decodes the merged rows into v like Decoder.Decode; a field tagged
+// `livestatus:"site"` receives the site name.
+func (r *MultiResult) Unmarshal(v any) error {
+	if err := checkSliceTarget(v); err != nil {
+		return err
+	}
+	return r.dec.decodeRows(r.Rows, v)
+}
+
+// Query sends q to the selected sites concurrently and merges the rows of
+// those that answered. Sites that fail or time out are reported in
+// MultiResult.Sites and don't fail the query; err is only set if no site is
+// selected, a selected name is unknown, or every site failed, in which case it
+// joins the site errors. Stats rows are returned per site; use QueryMerged to
+// combine them.
+func (m *MultiSite) Query(ctx context.Context, q LiveStatusQuery, sel SiteSelector) (*MultiResult, error) {
+	answers, err := m.fanOut(ctx, &q, sel)
+	if err != nil {
+		return nil, err
+	}
+	return concat(&q, answers)
+}
+
+// QueryMerged sends q to the selected sites like Query and merges the results
+// (see Merge): Stats are combined across sites, and opts' ordering, offset and
+// limit apply to the merged rows.
+func (m *MultiSite) QueryMerged(ctx context.Context, q LiveStatusQuery, sel SiteSelector, opts MergeOptions) (*MultiResult, error) {
+	answers, err := m.fanOut(ctx, MergeQuery(&q, opts), sel)
+	if err != nil {
+		return nil, err
+	}
+	return merge(&q, answers, opts)
+}
+
+// siteAnswer is the response of one site to a fanned out query.
+type siteAnswer struct {
+	site string
+	res  *Result
+	err  error
+	took time.Duration
+}
+
+// fanOut sends q to the selected sites concurrently and returns their answers
+// by site name.
+func (m *MultiSite) fanOut(ctx context.Context, q *LiveStatusQuery, sel SiteSelector) ([]siteAnswer, error) {
+	sites, timeout, err := m.selectSites(sel)
+	if err != nil {
+		return nil, err
+	}
+	answers := make([]siteAnswer, len(sites))
+	var wg sync.WaitGroup
+	for i, s := range sites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := timeout
+			if s.Timeout > 0 {
+				d = s.Timeout
+			}
+			start := time.Now()
+			res, err := s.query(ctx, q, d)
+			answers[i] = siteAnswer{site: s.Name, res: res, err: err, took: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+	return answers, nil
+}
+
+// parseAnswer checks a site's answer to q and decodes its rows. The header row,
+// if q gets one, is returned separately.
+func parseAnswer(q *LiveStatusQuery, a siteAnswer) (rows []Row, header Row, sr *SiteResult) {
+	sr = &SiteResult{Site: a.site, Duration: a.took}
+	if a.res != nil {
+		sr.StatusCode = a.res.StatusCode
+	}
+	switch {
+	case a.err != nil:
+		sr.Err = a.err
+		return nil, nil, sr
+	case a.res == nil:
+		sr.Err = fmt.Errorf("no result")
+		return nil, nil, sr
+	case a.res.Error != nil:
+		sr.Err = a.res.Error
+		return nil, nil, sr
+	case a.res.StatusCode != StatusOK:
+		sr.Err = statusError(a.res.StatusCode, a.res.Data)
+		return nil, nil, sr
+	}
+	rows, err := parseRows(q.outputFormat, a.res.Data, q.separators())
+	if err != nil {
+		sr.Err = fmt.Errorf("parse response: %w", err)
+		return nil, nil, sr
+	}
+	if hasHeaderRow(q) && len(rows) > 0 {
+		header, rows = rows[0], rows[1:]
+	}
+	sr.Rows = len(rows)
+	return rows, header, sr
+}
+
+// hasHeaderRow reports whether the response to q starts with the column names.
+// Like DecodeStats, it expects one for Stats queries only with ColumnHeaders on.
+func hasHeaderRow(q *LiveStatusQuery) bool {
+	if len(q.statsAggs) > 0 {
+		return q.columnHdrs != nil && *q.columnHdrs
+	}
+	return NewDecoder(q).headers
+}
+
+// concat prefixes the rows of every site that answered with its name and
+// appends them in site order.
+func concat(q *LiveStatusQuery, answers []siteAnswer) (*MultiResult, error) {
+	dec := NewDecoder(q)
+	out := &MultiResult{Sites: make(map[string]*SiteResult, len(answers)), dec: dec}
+	var errs []error
+	for _, a := range answers {
+		rows, header, sr := parseAnswer(q, a)
+		out.Sites[sr.Site] = sr
+		if sr.Err != nil {
+			errs = append(errs, fmt.Errorf("site %s: %w", sr.Site, sr.Err))
+			continue
+		}
+		if out.Columns == nil {
+			if header != nil {
+				dec.SetHeader(header)
+			}
+			out.Columns = append([]string{SiteColumn}, dec.Columns()...)
+		}
+		for _, row := range rows {
+			out.Rows = append(out.Rows, append(Row{sr.Site}, row...))
+		}
+	}
+	dec.columns = out.Columns
+	if len(errs) == len(answers) {
+		return out, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// selectSites returns the selected sites by name and the default timeout.
+func (m *MultiSite) selectSites(sel SiteSelector) ([]*Site, time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, name := range sel.Names {
+		if _, ok := m.sites[name]; !ok {
+			return nil, 0, fmt.Errorf("unknown site %q", name)
+		}
+	}
+	var sites []*Site
+	for _, s := range m.sites {
+		if sel.matches(s) {
+			sites = append(sites, s)
+		}
+	}
+	if len(sites) == 0 {
+		return nil, 0, ErrNoSites
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].Name < sites[j].Name })
+	return sites, m.timeout, nil
+}
+
+// query runs q on the site, bounded by timeout.
+func (s *Site) query(ctx context.Context, q *LiveStatusQuery, timeout time.Duration) (*Result, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if s.AuthUser != "" {
+		q = q.Clone().AuthUser(s.AuthUser)
+	}
+	if s.Actor != nil {
+		return s.Actor.Query(ctx, *q)
+	}
+	res, err := QueryOneOffFromBuilder(ctx, q, s.Config)
+	if cause := aborted(ctx); err != nil && cause != nil {
+		// The deadline reached the socket first; report it as the timeout it is.
+		err = fmt.Errorf("%w: %v", cause, err)
+	}
+	return res, err
+}
@@ -0,0 +1,162 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeSite starts a fake Livestatus answering every query with body.
+func fakeSite(t *testing.T, body string) *LiveStatusConfig {
+	t.Helper()
+	addr, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, body })
+	return NewLiveStatusConfig(addr)
+}
+
+func TestMultiSiteMergesRows(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "b", fakeSite(t, `[["web02",0],["web03",1]]`), 4, nil, prometheus.NewRegistry())
+	is.NoErr(actor.Start(context.Background()))
+	defer actor.Close()
+
+	m, err := NewMultiSite(
+		Site{Name: "b", Actor: actor},
+		Site{Name: "a", Config: fakeSite(t, `[["web01",2]]`)},
+	)
+	is.NoErr(err)
+	q := NewLiveStatusQuery(Table("hosts"), "name", "state").OutputFormat(OutputJSON)
+
+	res, err := m.Query(context.Background(), *q, SiteSelector{})
+	is.NoErr(err)
+	is.Equal(res.Columns, []string{"site", "name", "state"})
+	is.Equal(len(res.Rows), 3)
+	is.Equal(res.Rows[0][0], "a") // by site name
+	is.Equal(res.Rows[0][1], "web01")
+	is.Equal(res.Rows[2][:2], Row{"b", "web03"})
+	is.Equal(res.Sites["a"].Rows, 1)
+	is.Equal(res.Sites["b"].Rows, 2)
+	is.Equal(len(res.Failed()), 0)
+
+	var hosts []struct {
+		Site  string `livestatus:"site"`
+		Name  string `livestatus:"name"`
+		State int    `livestatus:"state"`
+	}
+	is.NoErr(res.Unmarshal(&hosts))
+	is.Equal(len(hosts), 3)
+	is.Equal(hosts[1].Site, "b")
+	is.Equal(hosts[1].Name, "web02")
+	is.Equal(hosts[2].State, 1)
+}
+
+func TestMultiSiteHeaderRows(t *testing.T) {
+	is := is.New(t)
+	m, err := NewMultiSite(
+		Site{Name: "a", Config: fakeSite(t, `[["name","state"],["web01",0]]`)},
+		Site{Name: "b", Config: fakeSite(t, `[["name","state"],["web02",1]]`)},
+	)
+	is.NoErr(err)
+
+	res, err := m.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")).OutputFormat(OutputJSON), SiteSelector{})
+	is.NoErr(err)
+	is.Equal(res.Columns, []string{"site", "name", "state"})
+	is.Equal(len(res.Rows), 2)
+	is.Equal(res.Rows[1][:2], Row{"b", "web02"})
+}
+
+func TestMultiSitePartialFailure(t *testing.T) {
+	is := is.New(t)
+	slow, _ := startFakeLivestatus(t, func(string) (int, string) {
+		time.Sleep(500 * time.Millisecond)
+		return StatusOK, `[["late"]]`
+	})
+	broken, _ := startFakeLivestatus(t, func(string) (int, string) {
+		return StatusNotFound, "Table 'hosts' does not exist"
+	})
+	m, err := NewMultiSite(
+		Site{Name: "ok", Config: fakeSite(t, `[["web01"]]`)},
+		Site{Name: "slow", Config: NewLiveStatusConfig(slow), Timeout: 50 * time.Millisecond},
+		Site{Name: "broken", Config: NewLiveStatusConfig(broken)},
+	)
+	is.NoErr(err)
+
+	start := time.Now()
+	res, err := m.Query(context.Background(), *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON), SiteSelector{})
+	is.NoErr(err) // one site answered
+	is.True(time.Since(start) < 400*time.Millisecond)
+	is.Equal(len(res.Rows), 1)
+	is.Equal(res.Failed(), []string{"broken", "slow"})
+	is.True(errors.Is(res.Sites["slow"].Err, context.DeadlineExceeded))
+	is.Equal(res.Sites["broken"].StatusCode, StatusNotFound)
+	is.True(strings.Contains(res.Sites["broken"].Err.Error(), "does not exist"))
+}
+
+func TestMultiSiteAllFailed(t *testing.T) {
+	is := is.New(t)
+	m, err := NewMultiSite(Site{Name: "down", Config: NewLiveStatusConfig("127.0.0.1:1")})
+	is.NoErr(err)
+
+	res, err := m.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")), SiteSelector{})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "site down:"))
+	is.Equal(res.Failed(), []string{"down"})
+}
+
+func TestMultiSiteSelection(t *testing.T) {
+	is := is.New(t)
+	m, err := NewMultiSite(
+		Site{Name: "eu1", Labels: map[string]string{"region": "eu", "tier": "prod"}, Config: fakeSite(t, `[["eu1"]]`)},
+		Site{Name: "eu2", Labels: map[string]string{"region": "eu", "tier": "test"}, Config: fakeSite(t, `[["eu2"]]`)},
+		Site{Name: "us1", Labels: map[string]string{"region": "us", "tier": "prod"}, Config: fakeSite(t, `[["us1"]]`)},
+	)
+	is.NoErr(err)
+	q := *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON)
+
+	sites := func(res *MultiResult) []string {
+		var names []string
+		for _, row := range res.Rows {
+			names = append(names, row[0].(string))
+		}
+		return names
+	}
+
+	res, err := m.Query(context.Background(), q, SiteSelector{Labels: map[string]string{"region": "eu"}})
+	is.NoErr(err)
+	is.Equal(sites(res), []string{"eu1", "eu2"})
+
+	res, err = m.Query(context.Background(), q, SiteSelector{Names: []string{"eu1", "us1"}, Labels: map[string]string{"tier": "prod"}})
+	is.NoErr(err)
+	is.Equal(sites(res), []string{"eu1", "us1"})
+
+	_, err = m.Query(context.Background(), q, SiteSelector{Labels: map[string]string{"region": "ap"}})
+	is.True(errors.Is(err, ErrNoSites))
+
+	_, err = m.Query(context.Background(), q, SiteSelector{Names: []string{"nope"}})
+	is.True(err != nil)
+}
+
+func TestMultiSiteAddRemove(t *testing.T) {
+	is := is.New(t)
+	m, err := NewMultiSite()
+	is.NoErr(err)
+	cfg := NewLiveStatusConfig("127.0.0.1:1")
+
+	is.True(m.Add(Site{Config: cfg}) != nil) // no name
+	is.True(m.Add(Site{Name: "a"}) != nil)   // no actor or config
+	is.NoErr(m.Add(Site{Name: "b", Config: cfg}))
+	is.NoErr(m.Add(Site{Name: "a", Config: cfg}))
+	is.True(m.Add(Site{Name: "a", Config: cfg}) != nil) // duplicate
+	is.Equal(m.Sites(), []string{"a", "b"})
+
+	is.True(m.Remove("a"))
+	is.True(!m.Remove("a"))
+	is.Equal(m.Sites(), []string{"b"})
+}
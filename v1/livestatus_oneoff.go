@@ -1,58 +1,120 @@
 package livestatus
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net"
+	"strings"
 	"time"
 )
 
 // QueryOneOff executes a livestatus query directly without using the actor.
 // This connects to the livestatus service, runs the query, and returns the result.
+// A "COMMAND ..." request is written without waiting for a response.
+//
+// The request is sent with "ResponseHeader: fixed16" unless it names another
+// response header, so the response is framed, capped at MaxBodyBytes and carries
+// the Livestatus status: a non-200 status is reported in Result.StatusCode and
+// Result.Error with the server's message. A query that explicitly asks for
+// "ResponseHeader: off" gets the raw output, read until the server closes the
+// connection. Connection, write and read failures are returned as the error.
 func QueryOneOff(ctx context.Context, query string, config *LiveStatusConfig) (*Result, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config cannot be nil")
 	}
+	request, framed, err := oneOffRequest(query)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create connection timeout context
-	connectCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
-	defer cancel()
-
-	// Connect to livestatus
-	conn, err := connectToLiveStatus(connectCtx, config)
+	conn, reader, err := ensureConn(ctx, config, nil)
 	if err != nil {
-		return &Result{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to connect to livestatus: %w", err),
-		}, nil
+		return nil, err
 	}
 	defer conn.Close()
+	// Deadlines come from the config and ctx.Deadline; this covers plain cancellation.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
 
-	// Set read timeout
-	if config.ReadTimeout > 0 {
-		_ = conn.SetReadDeadline(time.Now().Add(config.ReadTimeout))
+	if err := writeWithDeadline(ctx, config, conn, request); err != nil {
+		return nil, err
 	}
 
-	// Send query
-	if err := sendQuery(conn, query); err != nil {
-		return &Result{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to send query: %w", err),
-		}, nil
+	// Commands get no response; Livestatus may keep the connection open, so
+	// reading would only wait for the timeout.
+	if isCommandRequest(request) {
+		return &Result{StatusCode: StatusOK}, nil
 	}
 
-	// Read response
-	data, err := readResponse(conn)
-	if err != nil {
-		return &Result{
-			StatusCode: 500,
-			Error:      fmt.Errorf("failed to read response: %w", err),
-		}, nil
+	if !framed {
+		data, err := readRawResponse(ctx, config, conn, reader)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{StatusCode: StatusOK, Data: data}, nil
+	}
+	return readResponseFixed16(ctx, config, conn, reader)
+}
+
+// oneOffRequest terminates a raw request with a blank line and adds
+// "ResponseHeader: fixed16" to queries that don't name a response header.
+// framed is false when the query asks for unframed output.
+func oneOffRequest(query string) (request string, framed bool, err error) {
+	body := strings.TrimRight(query, "\r\n")
+	if isCommandRequest(body) {
+		return body + "\n\n", false, nil
+	}
+
+	framed = true
+	keepAlive := false
+	header := ""
+	for _, line := range strings.Split(body, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ResponseHeader":
+			header = strings.TrimSpace(value)
+		case "KeepAlive":
+			keepAlive = strings.TrimSpace(value) == "on"
+		}
 	}
+	switch header {
+	case "":
+		body += "\nResponseHeader: fixed16"
+	case "fixed16":
+	case "off":
+		if keepAlive {
+			// The end of raw output is only marked by the server closing the connection.
+			return "", false, fmt.Errorf("ResponseHeader: off cannot be combined with KeepAlive: on")
+		}
+		framed = false
+	default:
+		return "", false, fmt.Errorf("unsupported ResponseHeader %q", header)
+	}
+	return body + "\n\n", framed, nil
+}
 
-	return &Result{
-		StatusCode: 200,
-		Data:       data,
-	}, nil
+// readRawResponse reads an unframed response up to EOF, enforcing the
+// configured size cap.
+func readRawResponse(ctx context.Context, cfg *LiveStatusConfig, conn net.Conn, reader *bufio.Reader) ([]byte, error) {
+	if dl := ioDeadline(ctx, cfg.ReadTimeout); !dl.IsZero() {
+		_ = conn.SetReadDeadline(dl)
+	}
+	maxBody := maxBodyBytes(cfg)
+	data, err := io.ReadAll(io.LimitReader(reader, int64(maxBody)+1))
+	if err != nil {
+		return nil, &transportError{fmt.Errorf("failed reading response: %w", err)}
+	}
+	if len(data) > maxBody {
+		return nil, fmt.Errorf("response too large: exceeds cap of %d bytes", maxBody)
+	}
+	return data, nil
 }
 
 // QueryOneOffFromBuilder executes a query built with LiveStatusQuery directly.
@@ -62,3 +124,53 @@ func QueryOneOffFromBuilder(ctx context.Context, query *LiveStatusQuery, config
 	}
 	return QueryOneOff(ctx, query.Build(), config)
 }
+
+// SendCommandOneOff submits an external command over a dedicated connection.
+// A nil error means the command was written; Livestatus does not report whether
+// the core accepted it.
+func SendCommandOneOff(ctx context.Context, cmd *Command, config *LiveStatusConfig) error {
+	if cmd == nil {
+		return fmt.Errorf("command cannot be nil")
+	}
+	res, err := QueryOneOff(ctx, cmd.Build(), config)
+	if err != nil {
+		return err
+	}
+	return res.Error
+}
+
+// StreamOneOff executes a query over a dedicated connection and yields rows as
+// they are decoded, without buffering the whole response. The connection is
+// closed when the iteration ends.
+func StreamOneOff(ctx context.Context, query *LiveStatusQuery, config *LiveStatusConfig) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		if config == nil {
+			yield(nil, fmt.Errorf("config cannot be nil"))
+			return
+		}
+		if query == nil {
+			yield(nil, fmt.Errorf("query cannot be nil"))
+			return
+		}
+		conn, reader, err := ensureConn(ctx, config, nil)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer conn.Close()
+
+		stopped := false
+		_, err = execStreamOverPersistentConn(slog.New(slog.DiscardHandler), ctx, config, conn, reader, *query, func(row Row) bool {
+			if !yield(row, nil) {
+				// The connection is not reused, so close it instead of draining the rest.
+				stopped = true
+				_ = conn.Close()
+				return false
+			}
+			return true
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
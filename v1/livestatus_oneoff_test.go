@@ -2,6 +2,8 @@ package livestatus
 
 import (
 	"context"
+	"io"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -38,23 +40,128 @@ func TestQueryOneOffConnectionError(t *testing.T) {
 	config.ConnectTimeout = 100 * time.Millisecond
 
 	result, err := QueryOneOff(ctx, "GET hosts", config)
-	is.NoErr(err) // QueryOneOff returns a Result with error info on failures
-	is.Equal(result.StatusCode, 500)
-	is.True(result.Error != nil)
-	is.True(strings.Contains(result.Error.Error(), "failed to connect"))
+	is.True(err != nil) // transport failures are returned as the error
+	is.True(result == nil)
+	is.True(strings.Contains(err.Error(), "failed to connect"))
 }
 
 func TestQueryOneOffFromBuilder(t *testing.T) {
 	is := is.New(t)
 
-	ctx := context.Background()
+	var got string
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		got = req
+		return 200, `[["srv1",0]]`
+	})
 	query := NewLiveStatusQuery(Table("hosts"), "name", "state").OutputFormat(OutputJSON)
-	config := NewLiveStatusConfig("localhost:6557")
-	// Fail fast since no real livestatus server is likely available
-	config.ConnectTimeout = 100 * time.Millisecond
+	config := NewLiveStatusConfig(addr)
+
+	result, err := QueryOneOffFromBuilder(context.Background(), query, config)
+	is.NoErr(err)
+	is.Equal(result.StatusCode, StatusOK)
+	is.NoErr(result.Error)
+	is.Equal(string(result.Data), `[["srv1",0]]`)
+	is.True(strings.Contains(got, "ResponseHeader: fixed16\n"))
+	is.Equal(strings.Count(got, "ResponseHeader:"), 1)
+	is.True(!strings.Contains(query.Build(), "ResponseHeader")) // caller's query untouched
+}
+
+func TestQueryOneOffStatusError(t *testing.T) {
+	is := is.New(t)
+
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return 404, "Invalid GET request, no such table 'hostz'\n"
+	})
+	result, err := QueryOneOff(context.Background(), "GET hostz\nColumns: name\n\n", NewLiveStatusConfig(addr))
+	is.NoErr(err)
+	is.Equal(result.StatusCode, 404)
+	is.True(result.Error != nil)
+	is.Equal(result.Error.Error(), "livestatus status 404: Invalid GET request, no such table 'hostz'")
+	is.Equal(len(result.Data), 0)
+}
+
+func TestQueryOneOffSizeCap(t *testing.T) {
+	is := is.New(t)
+
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return 200, strings.Repeat("x", 64)
+	})
+	config := NewLiveStatusConfig(addr)
+	config.MaxBodyBytes = 16
+
+	_, err := QueryOneOff(context.Background(), "GET hosts", config)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "response too large"))
 
-	// This will likely fail due to no actual livestatus server, but exercises the function
-	result, err := QueryOneOffFromBuilder(ctx, query, config)
+	_, err = QueryOneOff(context.Background(), "GET hosts\nResponseHeader: off", config)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "response too large"))
+}
+
+func TestQueryOneOffRawOutput(t *testing.T) {
+	is := is.New(t)
+
+	var got string
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		got = req
+		return 200, "srv1;0\nsrv2;1\n"
+	})
+	result, err := QueryOneOff(context.Background(), "GET hosts\nColumns: name state\nResponseHeader: off\n\n", NewLiveStatusConfig(addr))
 	is.NoErr(err)
-	is.True(result != nil)
+	is.Equal(result.StatusCode, StatusOK)
+	is.Equal(string(result.Data), "srv1;0\nsrv2;1\n")
+	is.True(!strings.Contains(got, "fixed16"))
+}
+
+func TestQueryOneOffReadTimeout(t *testing.T) {
+	is := is.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn) // never answers
+	}()
+	config := NewLiveStatusConfig(ln.Addr().String())
+	config.ReadTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err = QueryOneOff(context.Background(), "GET hosts", config)
+	is.True(err != nil)
+	is.True(isTransportError(err))
+	is.True(time.Since(start) < 2*time.Second)
+}
+
+func TestOneOffRequest(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		query   string
+		request string
+		framed  bool
+		err     bool
+	}{
+		{"GET hosts", "GET hosts\nResponseHeader: fixed16\n\n", true, false},
+		{"GET hosts\nColumns: name\n\n", "GET hosts\nColumns: name\nResponseHeader: fixed16\n\n", true, false},
+		{"GET hosts\nResponseHeader: fixed16\n", "GET hosts\nResponseHeader: fixed16\n\n", true, false},
+		{"GET hosts\nResponseHeader: off", "GET hosts\nResponseHeader: off\n\n", false, false},
+		{"GET hosts\nResponseHeader: off\nKeepAlive: on", "", false, true},
+		{"GET hosts\nResponseHeader: bogus", "", false, true},
+		{"COMMAND [1] ENABLE_NOTIFICATIONS\n", "COMMAND [1] ENABLE_NOTIFICATIONS\n\n", false, false},
+	}
+	for _, tt := range tests {
+		request, framed, err := oneOffRequest(tt.query)
+		if tt.err {
+			is.True(err != nil)
+			continue
+		}
+		is.NoErr(err)
+		is.Equal(request, tt.request)
+		is.Equal(framed, tt.framed)
+	}
 }
@@ -0,0 +1,165 @@
+package livestatus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// pipelinedRequest is a query written to a connection whose response has not
+// been read yet.
+type pipelinedRequest struct {
+	item   *WorkItem
+	ctx    context.Context   // the item's execution context (see begin)
+	cfg    *LiveStatusConfig // the configuration the request was sent with
+	done   func()
+	conn   net.Conn
+	reader *bufio.Reader
+	start  time.Time
+	err    error // the request could not be sent; no response will come
+}
+
+// connPipeline tracks the requests outstanding on one pooled connection. The
+// worker writes and appends to pending; the connection's reader goroutine
+// consumes pending in the same order, which is the order Livestatus answers in.
+type connPipeline struct {
+	depth   int
+	pending chan *pipelinedRequest
+	slots   chan struct{} // one token per outstanding request
+	failed  atomic.Bool   // the connection broke with requests outstanding
+}
+
+func newConnPipeline(depth int) *connPipeline {
+	return &connPipeline{
+		depth:   depth,
+		pending: make(chan *pipelinedRequest, depth),
+		slots:   make(chan struct{}, depth),
+	}
+}
+
+// drain blocks until every outstanding request has been answered or failed.
+func (p *connPipeline) drain() {
+	for i := 0; i < p.depth; i++ {
+		p.slots <- struct{}{}
+	}
+	for i := 0; i < p.depth; i++ {
+		<-p.slots
+	}
+}
+
+// SetPipelineDepth lets each pooled connection carry up to k queries at once:
+// requests are written back-to-back and the fixed16 responses are matched to
+// their work items in FIFO order. If the connection fails, every item still
+// waiting for a response gets a failed Result. Streams, commands and health
+// checks wait for the pipeline to empty and then run alone. A pipelined request
+// that is cancelled or expires still has its response read, so the others keep
+// their place; only its Result reports the cancellation. Call it before Start;
+// the default of 1 keeps strict write-then-read.
+func (a *LiveStatusActor) SetPipelineDepth(k int) {
+	if k < 1 {
+		k = 1
+	}
+	a.pipelineDepth = k
+}
+
+// settle waits for c's outstanding requests and drops the connection if it broke
+// meanwhile, so c can be used for strict request/response again.
+func (a *LiveStatusActor) settle(c *actorConn) {
+	if c.pipe == nil {
+		return
+	}
+	c.pipe.drain()
+	if c.pipe.failed.Swap(false) {
+		a.closeConn(c)
+	}
+}
+
+// pipelineItem writes a query on c without waiting for earlier responses.
+func (a *LiveStatusActor) pipelineItem(c *actorConn, ctx context.Context, item *WorkItem, done func()) {
+	if c.pipe.failed.Load() {
+		a.settle(c)
+	}
+	c.pipe.slots <- struct{}{} // blocks while depth requests are outstanding
+	a.metrics.IncrementInFlightForConn(c.label)
+
+	req := &pipelinedRequest{item: item, ctx: ctx, cfg: c.cfg, done: done, start: time.Now()}
+	// Whatever happens, the reader gets the request and completes the item.
+	defer func() {
+		if r := recover(); r != nil {
+			a.logger.Error("Panic while pipelining livestatus query",
+				"panic", r,
+				"query", item.Query,
+				"id", item.ID,
+			)
+			a.metrics.IncrementPanics()
+			req.err = fmt.Errorf("panic: %v", r)
+			if c.conn != nil {
+				c.pipe.failed.Store(true)
+				_ = c.conn.Close()
+			}
+		}
+		c.pipe.pending <- req
+	}()
+	if len(c.pipe.slots) == 1 || c.conn == nil {
+		// Nothing else is outstanding on a connection (a failed dial leaves
+		// none), so the reader is idle and the liveness probe in ensureConn
+		// cannot steal response bytes.
+		conn, reader, err := ensureConn(a.ctx, c.cfg, c.conn)
+		if err != nil {
+			req.err = err
+			a.connFailed(c, err)
+		} else {
+			c.set(conn, reader)
+		}
+	}
+	if req.err == nil {
+		req.conn, req.reader = c.conn, c.reader
+		_, err := writeRequest(a.logger, a.ctx, c.cfg, c.conn, item.Query)
+		_ = c.conn.SetWriteDeadline(time.Time{})
+		if err != nil {
+			req.err = err
+			// The responses to earlier requests can't be trusted anymore either;
+			// closing makes the reader fail them.
+			c.pipe.failed.Store(true)
+			_ = c.conn.Close()
+		}
+	}
+}
+
+// readLoop reads the responses of c's pipelined requests in write order and
+// completes their items. It exits once the worker closes pending.
+func (a *LiveStatusActor) readLoop(c *actorConn) {
+	defer a.wg.Done()
+	var broken net.Conn // failed mid-pipeline; later requests on it fail unread
+	for req := range c.pipe.pending {
+		var res *Result
+		err := req.err
+		if err == nil && broken != nil && req.conn == broken {
+			err = &transportError{fmt.Errorf("connection failed with request outstanding")}
+		}
+		if err == nil {
+			res, err = readResponseFixed16(a.ctx, req.cfg, req.conn, req.reader)
+			if isTransportError(err) {
+				broken = req.conn
+				c.pipe.failed.Store(true)
+				_ = req.conn.Close()
+			} else {
+				a.connOK(c)
+			}
+		}
+		if err != nil {
+			res = &Result{StatusCode: StatusInternalServerError, Error: err}
+		}
+		if cause := aborted(req.ctx); cause != nil {
+			res = abortedResult(cause)
+		}
+		a.metrics.ObserveProcessingSeconds(time.Since(req.start).Seconds())
+		a.metrics.DecrementInFlightForConn(c.label)
+		a.complete(req.item, res)
+		req.done()
+		<-c.pipe.slots
+	}
+}
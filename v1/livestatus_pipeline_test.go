@@ -0,0 +1,156 @@
+package livestatus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPipelineMatchesResponsesInOrder(t *testing.T) {
+	is := is.New(t)
+	release := make(chan struct{})
+	addr, accepted := startFakeLivestatus(t, func(req string) (int, string) {
+		table := strings.TrimPrefix(strings.SplitN(req, "\n", 2)[0], "GET ")
+		if table == "t0" {
+			<-release
+		}
+		return StatusOK, table
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 8)
+	actor := NewLiveStatusActor(logger, "test_pipeline", NewLiveStatusConfig(addr), 8, results, prometheus.NewRegistry())
+	actor.SetPipelineDepth(3)
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ids := map[RequestID]string{}
+	for i := 0; i < 4; i++ {
+		table := fmt.Sprintf("t%d", i)
+		id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table(table)))
+		is.NoErr(err)
+		ids[id] = table
+	}
+
+	// Three requests are on the wire while the first is stuck; the fourth waits.
+	inFlight := actor.metrics.inFlight.WithLabelValues("test_pipeline", "0")
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(inFlight) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	is.Equal(testutil.ToFloat64(inFlight), 3.0)
+	close(release)
+
+	for i := 0; i < 4; i++ {
+		msg := recvResult(t, results, 2*time.Second)
+		is.NoErr(msg.Result.Error)
+		is.Equal(string(msg.Result.Data), ids[msg.ID]) // each response went to its own item
+		is.Equal(string(msg.Result.Data), fmt.Sprintf("t%d", i))
+	}
+	is.Equal(accepted.Load(), int32(1))
+}
+
+func TestPipelineFailureFailsOutstanding(t *testing.T) {
+	is := is.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	defer ln.Close()
+	go func() {
+		// First connection: swallow three requests, then hang up without answering.
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		r := bufio.NewReader(conn)
+		for blank := 0; blank < 3; {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			if line == "\n" {
+				blank++
+			}
+		}
+		conn.Close()
+
+		// Second connection: answer one request.
+		conn, err = ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r = bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\n" {
+				break
+			}
+		}
+		fmt.Fprintf(conn, "%03d %11d\n%s", StatusOK, 2, "ok")
+	}()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 8)
+	actor := NewLiveStatusActor(logger, "test_pipeline_fail", NewLiveStatusConfig(ln.Addr().String()), 8, results, prometheus.NewRegistry())
+	actor.SetPipelineDepth(4)
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	want := map[RequestID]bool{}
+	for i := 0; i < 3; i++ {
+		id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("hosts")))
+		is.NoErr(err)
+		want[id] = true
+	}
+	for i := 0; i < 3; i++ {
+		msg := recvResult(t, results, 2*time.Second)
+		is.True(want[msg.ID])
+		delete(want, msg.ID)
+		is.True(msg.Result.Error != nil)
+		is.Equal(msg.Result.StatusCode, StatusInternalServerError)
+	}
+	is.Equal(len(want), 0)
+
+	// The worker drops the broken connection and redials for the next query.
+	id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, id)
+	is.NoErr(msg.Result.Error)
+	is.Equal(string(msg.Result.Data), "ok")
+}
+
+func TestPipelineUnreachableSite(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 32)
+	actor := NewLiveStatusActor(logger, "test_pipeline", NewLiveStatusConfig("127.0.0.1:1"), 32, results, prometheus.NewRegistry())
+	actor.SetPipelineDepth(4)
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	// Later items find other failed requests outstanding and no connection.
+	for i := 0; i < 20; i++ {
+		_, ok := actor.TrySendQuery(*NewLiveStatusQuery(Table("hosts")))
+		is.True(ok)
+	}
+	for i := 0; i < 20; i++ {
+		msg := recvResult(t, results, 2*time.Second)
+		is.Equal(msg.Result.StatusCode, StatusInternalServerError)
+		is.True(msg.Result.Error != nil)
+	}
+	is.Equal(testutil.ToFloat64(actor.metrics.panicsTotal.WithLabelValues("test_pipeline")), 0.0)
+}
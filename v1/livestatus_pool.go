@@ -0,0 +1,83 @@
+package livestatus
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// actorConn is one persistent connection of the actor's pool together with its
+// health state. It is owned by a single worker goroutine; only interrupt may be
+// called from elsewhere.
+type actorConn struct {
+	id    int
+	label string // id as the "conn" metric label
+
+	mu     sync.Mutex // guards conn against interrupt; the worker reads it freely
+	conn   net.Conn
+	reader *bufio.Reader
+
+	activeSince time.Time
+	state       atomic.Int32
+
+	pipe *connPipeline // nil unless the actor pipelines requests (see SetPipelineDepth)
+
+	cfg         *LiveStatusConfig // the worker's view of the actor's configuration
+	reconfigure chan struct{}     // signalled by UpdateConfig
+
+	onProcessExit func(err error) // called when a CommandDialer process exits by itself
+}
+
+func newActorConn(id int) *actorConn {
+	return &actorConn{id: id, label: strconv.Itoa(id), reconfigure: make(chan struct{}, 1)}
+}
+
+// set installs a (possibly new) connection after ensureConn.
+func (c *actorConn) set(conn net.Conn, reader *bufio.Reader) {
+	c.mu.Lock()
+	prev := c.conn
+	c.conn, c.reader = conn, reader
+	c.mu.Unlock()
+	if pc, ok := conn.(processConn); ok && conn != prev && c.onProcessExit != nil {
+		go c.watchProcess(pc)
+	}
+}
+
+// watchProcess reports the process behind a connection exiting on its own,
+// which otherwise would only be noticed by the next request or health check.
+func (c *actorConn) watchProcess(pc processConn) {
+	<-pc.exited()
+	if err := pc.exitErr(); err != nil {
+		c.onProcessExit(err)
+	}
+}
+
+// interrupt unblocks any I/O in progress on the connection so its worker can
+// observe shutdown. The worker still closes the connection itself.
+func (c *actorConn) interrupt() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		_ = c.conn.SetDeadline(time.Now())
+	}
+}
+
+// SetPoolSize sets the number of persistent connections, each served by its own
+// worker, so a slow query (e.g. a large log pull) only occupies one of them.
+// Work items are taken from the shared queue by whichever worker is free, so
+// results may complete out of submission order. Call it before Start; the
+// default is 1, which keeps the single-worker behaviour.
+func (a *LiveStatusActor) SetPoolSize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.poolSize = n
+}
+
+// PoolSize returns the configured number of connections and workers.
+func (a *LiveStatusActor) PoolSize() int {
+	return a.poolSize
+}
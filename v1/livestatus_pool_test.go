@@ -0,0 +1,120 @@
+package livestatus
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPoolSlowQueryDoesNotBlock(t *testing.T) {
+	is := is.New(t)
+	release := make(chan struct{})
+	addr, accepted := startFakeLivestatus(t, func(req string) (int, string) {
+		if strings.HasPrefix(req, "GET log") {
+			<-release
+			return StatusOK, "[]"
+		}
+		return StatusOK, `[["web01"]]`
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+	results := make(chan ResultMsg, 4)
+	actor := NewLiveStatusActor(logger, "test_pool", NewLiveStatusConfig(addr), 4, results, reg)
+	actor.SetPoolSize(2)
+	defer actor.Close()
+	is.Equal(actor.PoolSize(), 2)
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	slowID, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("log"), "message").OutputFormat(OutputJSON))
+	is.NoErr(err)
+	time.Sleep(50 * time.Millisecond) // let a worker pick up the slow query first
+	fastID, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON))
+	is.NoErr(err)
+
+	// The cheap lookup completes on the second connection while the log pull is stuck.
+	msg := recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, fastID)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+	is.Equal(testutil.ToFloat64(actor.metrics.inFlight.WithLabelValues("test_pool", "0"))+
+		testutil.ToFloat64(actor.metrics.inFlight.WithLabelValues("test_pool", "1")), 1.0)
+
+	close(release)
+	msg = recvResult(t, results, 2*time.Second)
+	is.Equal(msg.ID, slowID)
+	is.NoErr(msg.Result.Error)
+	is.Equal(accepted.Load(), int32(2))
+}
+
+func TestPoolDefaultsToSingleWorker(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 4)
+	actor := NewLiveStatusActor(logger, "test_pool_default", nil, 4, results, prometheus.NewRegistry())
+	is.Equal(actor.PoolSize(), 1)
+	actor.SetPoolSize(0)
+	is.Equal(actor.PoolSize(), 1)
+	is.NoErr(actor.Start(context.Background()))
+	defer actor.Close()
+	is.Equal(len(actor.conns), 1)
+
+	// Simulation mode still processes items in submission order with one worker.
+	id1, _ := actor.TrySendQuery(*NewLiveStatusQuery(Table("hosts")))
+	id2, _ := actor.TrySendQuery(*NewLiveStatusQuery(Table("services")))
+	is.Equal(recvResult(t, results, time.Second).ID, id1)
+	is.Equal(recvResult(t, results, time.Second).ID, id2)
+}
+
+func TestPoolConnectivityEventsCarryConn(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, `[["web01"]]`
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+	actor := NewLiveStatusActor(logger, "test_pool_events", NewLiveStatusConfig(addr), 4, make(chan ResultMsg, 4), reg)
+	events := make(chan ConnectivityEvent, 8)
+	actor.SetEventChan(events)
+	actor.SetPoolSize(2)
+	is.NoErr(actor.Start(context.Background()))
+	defer actor.Close()
+
+	// Drive the health checks directly instead of waiting for the timers.
+	for _, c := range actor.conns {
+		actor.runHealthCheck(c)
+	}
+	seen := map[int]bool{}
+	for i := 0; i < 2; i++ {
+		ev := <-events
+		is.Equal(ev.State, StateConnected)
+		is.Equal(ev.Actor, "test_pool_events")
+		seen[ev.Conn] = true
+	}
+	is.True(seen[0] && seen[1])
+	is.Equal(testutil.ToFloat64(actor.metrics.clientConnected.WithLabelValues("test_pool_events", "1")), 1.0)
+	is.True(strings.Contains(ConnectivityEvent{Conn: 1}.ToJSON(), `"conn": 1`))
+}
+
+func TestMetricsWithoutConnUseFirstConnection(t *testing.T) {
+	is := is.New(t)
+	m := NewMetrics(prometheus.NewRegistry(), "test_pool_compat")
+
+	m.IncrementInFlight()
+	m.IncrementInFlightForConn("1")
+	m.SetClientConnected(1)
+	m.SetClientConnUptime(5)
+	is.Equal(testutil.ToFloat64(m.inFlight.WithLabelValues("test_pool_compat", "0")), 1.0)
+	is.Equal(testutil.ToFloat64(m.inFlight.WithLabelValues("test_pool_compat", "1")), 1.0)
+	is.Equal(testutil.ToFloat64(m.clientConnected.WithLabelValues("test_pool_compat", "0")), 1.0)
+	is.Equal(testutil.ToFloat64(m.clientConnUptimeSecs.WithLabelValues("test_pool_compat", "0")), 5.0)
+	m.DecrementInFlight()
+	is.Equal(testutil.ToFloat64(m.inFlight.WithLabelValues("test_pool_compat", "0")), 0.0)
+}
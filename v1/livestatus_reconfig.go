@@ -0,0 +1,96 @@
+package livestatus
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpdateConfig replaces the connection configuration of a running actor,
+// keeping its queue, metrics and breaker settings. Each worker applies the
+// change between work items: requests already in flight finish with the old
+// settings, and everything after uses the new ones. Workers whose connection
+// is affected (a different address, network, TLS settings or dialer) close it
+// and reconnect; timeout and MaxBodyBytes changes apply to the open
+// connection. With UseTLS every update reconnects, so certificate files
+// replaced in place are read again.
+//
+// Every worker publishes a ConnectivityEvent with Reason "reconfigured" once it
+// has switched: StateConnected if it kept an open connection, StateDisconnected
+// otherwise. An open circuit breaker closes again when the connection settings
+// change, so queued requests get a chance against the new address.
+//
+// cfg is copied. UpdateConfig may be called at any time except concurrently
+// with Start.
+func (a *LiveStatusActor) UpdateConfig(cfg *LiveStatusConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if _, err := cfg.dialer(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	next := *cfg
+	a.config.Store(&next)
+	a.logger.Info("configuration updated", "addr", next.Address)
+	for _, c := range a.conns {
+		select {
+		case c.reconfigure <- struct{}{}:
+		default: // already signalled
+		}
+	}
+	return nil
+}
+
+// Config returns a copy of the actor's current connection configuration, or
+// nil in simulation mode.
+func (a *LiveStatusActor) Config() *LiveStatusConfig {
+	cfg := a.config.Load()
+	if cfg == nil {
+		return nil
+	}
+	cp := *cfg
+	return &cp
+}
+
+// applyConfig switches c to the actor's current configuration if it changed.
+// Only c's worker calls it, between work items.
+func (a *LiveStatusActor) applyConfig(c *actorConn) {
+	cfg := a.config.Load()
+	if cfg == c.cfg {
+		return
+	}
+	a.settle(c)
+	prev := c.cfg
+	c.cfg = cfg
+	reconnect := needsReconnect(prev, cfg)
+	if reconnect {
+		if c.conn != nil {
+			a.closeConn(c)
+		}
+		// The breaker judged the old address; give the new one a fresh start.
+		if state, changed := a.breaker.success(); changed {
+			a.breakerChanged(c, state, nil)
+		}
+	}
+	state := StateDisconnected
+	if c.conn != nil {
+		state = StateConnected
+	}
+	a.logger.Debug("conn reconfigured", "conn", c.id, "reconnect", reconnect)
+	a.emit(c, ConnectivityEvent{State: state, Time: time.Now(), Reason: "reconfigured"})
+}
+
+// needsReconnect reports whether a connection opened with prev must be replaced
+// to honour next.
+func needsReconnect(prev, next *LiveStatusConfig) bool {
+	if prev == nil || next == nil {
+		return prev != next
+	}
+	// Dialers can't be compared, and TLS files may have changed on disk.
+	if prev.Dialer != nil || next.Dialer != nil || next.UseTLS {
+		return true
+	}
+	p, n := *prev, *next
+	p.ConnectTimeout, p.ReadTimeout, p.WriteTimeout, p.MaxBodyBytes = 0, 0, 0, 0
+	n.ConnectTimeout, n.ReadTimeout, n.WriteTimeout, n.MaxBodyBytes = 0, 0, 0, 0
+	return p != n
+}
@@ -0,0 +1,98 @@
+package livestatus
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestUpdateConfigSwitchesAddressBetweenItems(t *testing.T) {
+	is := is.New(t)
+	release := make(chan struct{})
+	a, _ := startFakeLivestatus(t, func(string) (int, string) {
+		<-release
+		return StatusOK, `[["a"]]`
+	})
+	b, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[["b"]]` })
+	actor, events := startTestActor(t, "test_reconfig", NewLiveStatusConfig(a), nil, func(actor *LiveStatusActor) { actor.SetPoolSize(1) })
+	q := *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON)
+
+	first := actor.Submit(q)
+	time.Sleep(50 * time.Millisecond) // first is in flight on a
+	queued := []*Future{actor.Submit(q), actor.Submit(q)}
+
+	is.NoErr(actor.UpdateConfig(NewLiveStatusConfig(b)))
+	close(release)
+
+	res, err := first.Wait(context.Background())
+	is.NoErr(err)
+	is.Equal(string(res.Data), `[["a"]]`) // finished on the old address
+	ev := recvEvent(t, events, "reconfigured", time.Second)
+	is.Equal(ev.State, StateDisconnected)
+	is.Equal(ev.Actor, "test_reconfig")
+	for _, f := range queued {
+		res, err := f.Wait(context.Background())
+		is.NoErr(err)
+		is.Equal(string(res.Data), `[["b"]]`)
+	}
+	is.Equal(actor.Config().Address, b)
+}
+
+func TestUpdateConfigKeepsConnectionForTimeouts(t *testing.T) {
+	is := is.New(t)
+	addr, accepted := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[]` })
+	actor, events := startTestActor(t, "test_reconfig", NewLiveStatusConfig(addr), nil, func(actor *LiveStatusActor) { actor.SetPoolSize(1) })
+	q := *NewLiveStatusQuery(Table("hosts"))
+
+	_, err := actor.Query(context.Background(), q)
+	is.NoErr(err)
+
+	cfg := actor.Config()
+	cfg.ReadTimeout = 5 * time.Second
+	is.NoErr(actor.UpdateConfig(cfg))
+	ev := recvEvent(t, events, "reconfigured", time.Second)
+	is.Equal(ev.State, StateConnected)
+
+	_, err = actor.Query(context.Background(), q)
+	is.NoErr(err)
+	is.Equal(accepted.Load(), int32(1))
+	is.Equal(actor.Config().ReadTimeout, 5*time.Second)
+}
+
+func TestUpdateConfigClosesBreaker(t *testing.T) {
+	is := is.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	dead := ln.Addr().String()
+	ln.Close()
+	addr, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[]` })
+	actor, events := startTestActor(t, "test_reconfig", NewLiveStatusConfig(dead), nil, func(actor *LiveStatusActor) {
+		actor.SetCircuitBreaker(CircuitBreaker{FailureThreshold: 1, ProbeInterval: time.Minute})
+		actor.SetPoolSize(1)
+	})
+	q := *NewLiveStatusQuery(Table("hosts"))
+
+	_, err = actor.Query(context.Background(), q)
+	is.NoErr(err)
+	is.Equal(recvEvent(t, events, "circuit_", time.Second).Reason, "circuit_open")
+
+	is.NoErr(actor.UpdateConfig(NewLiveStatusConfig(addr)))
+	is.Equal(recvEvent(t, events, "circuit_", time.Second).Reason, "circuit_closed")
+	res, err := actor.Query(context.Background(), q)
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+}
+
+func TestUpdateConfigRejectsInvalidConfig(t *testing.T) {
+	is := is.New(t)
+	actor, _ := startTestActor(t, "test_reconfig", NewLiveStatusConfig("mon:6557"), nil, func(actor *LiveStatusActor) { actor.SetPoolSize(1) })
+
+	is.True(actor.UpdateConfig(nil) != nil)
+	bad := NewLiveStatusConfig("mon:6557")
+	bad.Network = "udp"
+	is.True(actor.UpdateConfig(bad) != nil)
+	is.Equal(actor.Config().Address, "mon:6557")
+}
@@ -0,0 +1,169 @@
+package livestatus
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryClass selects which failures a RetryPolicy retries.
+type RetryClass uint8
+
+const (
+	// RetryConnect retries when no connection could be established.
+	RetryConnect RetryClass = 1 << iota
+	// RetryTransport retries when the connection broke during the request.
+	RetryTransport
+	// RetryServerError retries when Livestatus answered with a 5xx status.
+	RetryServerError
+)
+
+// RetryPolicy makes the actor retry failed requests on a fresh connection.
+// GET queries are retried for the selected classes of failure; COMMANDs only
+// with RetryCommands, since a command that was written before the connection
+// broke may be executed twice. Streams and pipelined requests are not retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // attempts including the first; 1 or less disables retries
+	InitialBackoff time.Duration // wait before the first retry (default 100ms)
+	MaxBackoff     time.Duration // cap on the wait (default 5s)
+	Multiplier     float64       // growth of the wait per retry (default 2)
+	// Jitter spreads each wait randomly by up to this fraction in either
+	// direction, e.g. 0.2 for ±20%, so workers don't retry in lockstep.
+	Jitter        float64
+	Classes       RetryClass // default RetryConnect|RetryTransport
+	RetryCommands bool
+}
+
+// DefaultRetryPolicy returns a policy of 3 attempts with 100ms exponential
+// backoff and ±20% jitter, retrying connect and transport failures.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Classes:        RetryConnect | RetryTransport,
+	}
+}
+
+// SetRetryPolicy enables retries. Each retry is counted in retries_total and
+// published as a ConnectivityEvent in StateRetrying, with Reason "retry",
+// Attempt set to the attempt about to be made and Backoff to the wait before it.
+// Call it before Start.
+func (a *LiveStatusActor) SetRetryPolicy(p RetryPolicy) {
+	def := DefaultRetryPolicy()
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = def.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = def.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = def.Multiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.Classes == 0 {
+		p.Classes = def.Classes
+	}
+	a.retry = p
+}
+
+// allows reports whether item may be tried again after failing attempt times
+// with a failure of class.
+func (p RetryPolicy) allows(item *WorkItem, class RetryClass, attempt int) bool {
+	if attempt >= p.MaxAttempts || p.Classes&class == 0 {
+		return false
+	}
+	return item.Command == nil || p.RetryCommands
+}
+
+// backoff returns the wait after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt && d < float64(p.MaxBackoff); i++ {
+		d *= p.Multiplier
+	}
+	d = min(d, float64(p.MaxBackoff))
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// retryReason is the metric label of a failure class.
+func retryReason(class RetryClass) string {
+	switch class {
+	case RetryConnect:
+		return "connect"
+	case RetryTransport:
+		return "transport"
+	case RetryServerError:
+		return "server_error"
+	default:
+		return "unknown"
+	}
+}
+
+// execute runs item on c, retrying according to the retry policy.
+func (a *LiveStatusActor) execute(c *actorConn, ctx context.Context, item *WorkItem) *Result {
+	for attempt := 1; ; attempt++ {
+		result, class := a.attempt(c, ctx, item)
+		if cause := aborted(ctx); class != 0 && cause != nil {
+			return abortedResult(cause)
+		}
+		if class == 0 || !a.retry.allows(item, class, attempt) || a.breaker.current() != BreakerClosed {
+			if class == 0 && attempt > 1 && c.conn != nil {
+				a.emit(c, ConnectivityEvent{State: StateConnected, Time: time.Now(), Reason: "recovered", Attempt: attempt})
+			}
+			return result
+		}
+
+		backoff := a.retry.backoff(attempt)
+		a.logger.Debug("retrying request", "id", item.ID, "conn", c.id, "attempt", attempt+1, "backoff", backoff, "err", result.Error)
+		a.metrics.IncrementRetries(retryReason(class))
+		a.emit(c, ConnectivityEvent{State: StateRetrying, Time: time.Now(), Reason: "retry", Attempt: attempt + 1, Backoff: backoff, Err: result.Error})
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return abortedResult(context.Cause(ctx))
+		}
+	}
+}
+
+// attempt makes a single try at item. class is the kind of failure, or zero
+// if the result is final (success, or an error no retry would fix).
+func (a *LiveStatusActor) attempt(c *actorConn, ctx context.Context, item *WorkItem) (*Result, RetryClass) {
+	conn, reader, err := ensureConn(ctx, c.cfg, c.conn)
+	if err != nil {
+		if aborted(ctx) == nil {
+			a.connFailed(c, err)
+		}
+		return &Result{StatusCode: StatusInternalServerError, Error: err}, RetryConnect
+	}
+	c.set(conn, reader)
+
+	var res *Result
+	if item.Command != nil {
+		// Commands get no response; success means the command was written.
+		err = execCommandOverPersistentConn(a.logger, ctx, c.cfg, c.conn, item.Command)
+		res = &Result{StatusCode: StatusOK}
+	} else {
+		res, err = execOverPersistentConn(a.logger, ctx, c.cfg, c.conn, c.reader, item.Query)
+	}
+	switch {
+	case isTransportError(err):
+		a.closeConn(c)
+		return &Result{StatusCode: StatusInternalServerError, Error: err}, RetryTransport
+	}
+	a.connOK(c)
+	switch {
+	case err != nil:
+		return &Result{StatusCode: StatusInternalServerError, Error: err}, 0
+	case res.StatusCode >= 500:
+		return res, RetryServerError
+	}
+	return res, 0
+}
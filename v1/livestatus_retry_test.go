@@ -0,0 +1,186 @@
+package livestatus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// flakyDialer fails the first failDials dials and hangs up on the first
+// dropRequests requests without answering; everything else gets an OK.
+func flakyDialer(dials *atomic.Int32, failDials, dropRequests int32) Dialer {
+	var requests atomic.Int32
+	return DialerFunc(func(ctx context.Context) (net.Conn, error) {
+		if dials.Add(1) <= failDials {
+			return nil, fmt.Errorf("connection refused")
+		}
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			buf := make([]byte, 4096)
+			for {
+				var req strings.Builder
+				for !strings.HasSuffix(req.String(), "\n\n") {
+					n, err := server.Read(buf)
+					if err != nil {
+						return
+					}
+					req.Write(buf[:n])
+				}
+				if strings.HasPrefix(req.String(), "COMMAND ") {
+					continue
+				}
+				if requests.Add(1) <= dropRequests {
+					return
+				}
+				body := `[["web01"]]`
+				fmt.Fprintf(server, "%03d %11d\n%s", StatusOK, len(body), body)
+			}
+		}()
+		return client, nil
+	})
+}
+
+// dialerConfig returns a config that connects through d.
+func dialerConfig(d Dialer) *LiveStatusConfig {
+	config := NewLiveStatusConfig("ignored")
+	config.Dialer = d
+	return config
+}
+
+func fastRetries() *RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.InitialBackoff = 10 * time.Millisecond
+	return &p
+}
+
+func TestRetryTransportFailure(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, events := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 0, 1)), nil, func(actor *LiveStatusActor) { actor.SetRetryPolicy(*fastRetries()) })
+
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts"), "name"))
+	is.NoErr(err)
+	is.NoErr(res.Error)
+	is.Equal(string(res.Data), `[["web01"]]`)
+	is.Equal(dials.Load(), int32(2)) // reconnected once
+	is.Equal(testutil.ToFloat64(actor.metrics.retriesTotal.WithLabelValues("test_retry", "transport")), 1.0)
+
+	var retry *ConnectivityEvent
+	for len(events) > 0 {
+		ev := <-events
+		if ev.Reason == "retry" {
+			retry = &ev
+		}
+	}
+	is.True(retry != nil)
+	is.Equal(retry.State, StateRetrying)
+	is.Equal(retry.Attempt, 2)
+	is.True(retry.Backoff >= 8*time.Millisecond && retry.Backoff <= 12*time.Millisecond) // 10ms ±20%
+	is.True(retry.Err != nil)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, _ := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 100, 0)), nil, func(actor *LiveStatusActor) { actor.SetRetryPolicy(*fastRetries()) })
+
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusInternalServerError)
+	is.True(strings.Contains(res.Error.Error(), "connection refused"))
+	is.Equal(dials.Load(), int32(3)) // MaxAttempts
+	is.Equal(testutil.ToFloat64(actor.metrics.retriesTotal.WithLabelValues("test_retry", "connect")), 2.0)
+}
+
+func TestNoRetryByDefault(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, _ := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 1, 0)), nil, nil)
+
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusInternalServerError)
+	is.Equal(dials.Load(), int32(1))
+}
+
+func TestCommandsAreNotRetried(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	actor, _ := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 1, 0)), nil, func(actor *LiveStatusActor) { actor.SetRetryPolicy(*fastRetries()) })
+
+	item := NewWorkItemFromCommand(nextRequestID(), EnableNotifications())
+	item.future = newFuture(item.ID)
+	is.NoErr(actor.Enqueue(context.Background(), item))
+	res, err := item.future.Wait(context.Background())
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusInternalServerError)
+	is.Equal(dials.Load(), int32(1))
+}
+
+func TestCommandRetryOptIn(t *testing.T) {
+	is := is.New(t)
+	var dials atomic.Int32
+	p := fastRetries()
+	p.RetryCommands = true
+	actor, _ := startTestActor(t, "test_retry", dialerConfig(flakyDialer(&dials, 1, 0)), nil, func(actor *LiveStatusActor) { actor.SetRetryPolicy(*p) })
+
+	item := NewWorkItemFromCommand(nextRequestID(), EnableNotifications())
+	item.future = newFuture(item.ID)
+	is.NoErr(actor.Enqueue(context.Background(), item))
+	res, err := item.future.Wait(context.Background())
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+	is.Equal(dials.Load(), int32(2))
+}
+
+func TestRetryServerErrorOptIn(t *testing.T) {
+	is := is.New(t)
+	var calls atomic.Int32
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		if calls.Add(1) == 1 {
+			return StatusServiceUnavailable, "core restarting\n"
+		}
+		return StatusOK, `[["web01"]]`
+	})
+	config := NewLiveStatusConfig(addr)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	actor := NewLiveStatusActor(logger, "test_retry", config, 4, nil, prometheus.NewRegistry())
+	p := fastRetries()
+	p.Classes = RetryServerError
+	actor.SetRetryPolicy(*p)
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+	is.Equal(calls.Load(), int32(2))
+	is.Equal(testutil.ToFloat64(actor.metrics.retriesTotal.WithLabelValues("test_retry", "server_error")), 1.0)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	is := is.New(t)
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	is.Equal(p.backoff(1), 100*time.Millisecond)
+	is.Equal(p.backoff(2), 200*time.Millisecond)
+	is.Equal(p.backoff(3), 400*time.Millisecond)
+	is.Equal(p.backoff(5), time.Second) // capped
+	is.Equal(p.backoff(50), time.Second)
+
+	p.Jitter = 0.5
+	for range 100 {
+		d := p.backoff(2)
+		is.True(d >= 100*time.Millisecond && d <= 300*time.Millisecond)
+	}
+}
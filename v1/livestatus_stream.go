@@ -0,0 +1,168 @@
+package livestatus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// streamBuffer bounds how many decoded rows may wait between the worker and the
+// consumer of a stream.
+const streamBuffer = 64
+
+type streamEvent struct {
+	row Row
+	err error
+}
+
+// rowStream connects the worker executing a streaming request with the
+// goroutine iterating over its rows.
+type rowStream struct {
+	rows     chan streamEvent
+	done     chan struct{} // closed by the consumer when it stops iterating
+	doneOnce sync.Once
+	endOnce  sync.Once
+}
+
+func newRowStream() *rowStream {
+	return &rowStream{
+		rows: make(chan streamEvent, streamBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+// stop tells the worker the consumer is gone.
+func (s *rowStream) stop() {
+	s.doneOnce.Do(func() { close(s.done) })
+}
+
+// stopped reports whether the consumer has stopped iterating.
+func (s *rowStream) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send delivers an event unless the consumer stopped or ctx ended.
+func (s *rowStream) send(ctx context.Context, ev streamEvent) bool {
+	select {
+	case s.rows <- ev:
+		return true
+	case <-s.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// finish reports a terminal error (if any) and ends the stream. Safe to call more than once.
+func (s *rowStream) finish(err error) {
+	s.endOnce.Do(func() {
+		if err != nil {
+			select {
+			case s.rows <- streamEvent{err: err}:
+			case <-s.done:
+			}
+		}
+		close(s.rows)
+	})
+}
+
+// StreamQuery executes the query on the actor's persistent connection and
+// yields rows as they are decoded from the socket, so memory stays bounded no
+// matter how large the response is. Stopping the iteration early is safe: the
+// worker drains the rest of the response and the connection stays usable.
+//
+// Results of streaming requests are not published to the results bus. If
+// ColumnHeaders is on, the first row yielded is the header row.
+func (a *LiveStatusActor) StreamQuery(ctx context.Context, query LiveStatusQuery) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		s := newRowStream()
+		defer s.stop()
+		item := &WorkItem{ID: nextRequestID(), Query: query, stream: s}
+		if err := a.Enqueue(ctx, item); err != nil {
+			yield(nil, err)
+			return
+		}
+		for {
+			select {
+			case ev, ok := <-s.rows:
+				if !ok {
+					return
+				}
+				if ev.err != nil {
+					yield(nil, ev.err)
+					return
+				}
+				if !yield(ev.row, nil) {
+					return
+				}
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+// processStream executes a streaming work item on the worker goroutine.
+func (a *LiveStatusActor) processStream(c *actorConn, ctx context.Context, item *WorkItem) {
+	s := item.stream
+	if s.stopped() {
+		// Consumer gave up while the item was queued; don't bother the site.
+		s.finish(nil)
+		return
+	}
+	yield := func(row Row) bool {
+		return s.send(ctx, streamEvent{row: row})
+	}
+
+	var (
+		code int
+		err  error
+	)
+	if c.cfg != nil {
+		stop := context.AfterFunc(ctx, c.interrupt)
+		defer stop()
+		conn, reader, cerr := ensureConn(ctx, c.cfg, c.conn)
+		if cerr != nil {
+			code, err = StatusInternalServerError, cerr
+			if aborted(ctx) == nil {
+				a.connFailed(c, cerr)
+			}
+		} else {
+			c.set(conn, reader)
+			code, err = execStreamOverPersistentConn(a.logger, ctx, c.cfg, c.conn, c.reader, item.Query, yield)
+			if isTransportError(err) {
+				a.closeConn(c)
+			} else if code != 0 {
+				a.connOK(c)
+			}
+			if code == 0 {
+				code = StatusInternalServerError
+			}
+		}
+	} else {
+		// Fallback simulation mirrors processOne, decoded through the row reader.
+		time.Sleep(50 * time.Millisecond)
+		body := fmt.Appendf(nil, "Processed: %s", item.Query.Build())
+		code, err = StatusOK, streamRows(&item.Query, bytes.NewReader(body), yield)
+	}
+	if cause := aborted(ctx); err != nil && cause != nil && !s.stopped() {
+		res := abortedResult(cause)
+		code, err = res.StatusCode, res.Error
+	}
+
+	a.metrics.IncrementProcessed(strconv.Itoa(code))
+	if err == nil && code == StatusOK {
+		a.metrics.UpdateLastSuccessTimestamp()
+	}
+	s.finish(err)
+}
@@ -0,0 +1,136 @@
+package livestatus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// logRows renders n CSV rows, enough to exceed a tiny MaxBodyBytes.
+func logRows(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%d;host%d;message %d\n", 1724000000+i, i, i)
+	}
+	return sb.String()
+}
+
+func TestStreamOneOff(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, logRows(1000)
+	})
+	cfg := NewLiveStatusConfig(addr)
+	cfg.MaxBodyBytes = 1024 // streaming is not bound by the buffered cap
+
+	q := NewLiveStatusQuery(Table("log"), "time", "host_name", "message")
+	n := 0
+	for row, err := range StreamOneOff(context.Background(), q, cfg) {
+		is.NoErr(err)
+		is.Equal(len(row), 3)
+		n++
+	}
+	is.Equal(n, 1000)
+
+	// Early stop ends the iteration without an error.
+	n = 0
+	for _, err := range StreamOneOff(context.Background(), q, cfg) {
+		is.NoErr(err)
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	is.Equal(n, 2)
+}
+
+func TestStreamOneOffSlowConsumer(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, logRows(5000) // well beyond what the readers buffer
+	})
+	cfg := NewLiveStatusConfig(addr)
+	cfg.ReadTimeout = 100 * time.Millisecond
+
+	q := NewLiveStatusQuery(Table("log"), "time", "host_name", "message")
+	n := 0
+	for _, err := range StreamOneOff(context.Background(), q, cfg) {
+		is.NoErr(err) // the body takes longer than ReadTimeout, but each read doesn't
+		if n%1000 == 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		n++
+	}
+	is.Equal(n, 5000)
+}
+
+func TestStreamQueryActorEarlyStop(t *testing.T) {
+	is := is.New(t)
+	addr, accepted := startFakeLivestatus(t, func(req string) (int, string) {
+		if strings.HasPrefix(req, "GET log") {
+			return StatusOK, logRows(5000)
+		}
+		return StatusOK, `[["web01"]]`
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 4)
+	actor := NewLiveStatusActor(logger, "test_stream", NewLiveStatusConfig(addr), 4, results, prometheus.NewRegistry())
+	defer actor.Close()
+	is.NoErr(actor.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n := 0
+	for row, err := range actor.StreamQuery(ctx, *NewLiveStatusQuery(Table("log"), "time", "host_name", "message")) {
+		is.NoErr(err)
+		is.Equal(row[1], "host0")
+		n++
+		break
+	}
+	is.Equal(n, 1)
+
+	// The persistent connection must still be in sync for the next request.
+	id, err := actor.SendQuery(ctx, *NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON))
+	is.NoErr(err)
+	msg := recvResult(t, results, 5*time.Second)
+	is.Equal(msg.ID, id)
+	is.NoErr(msg.Result.Error)
+	is.Equal(string(msg.Result.Data), `[["web01"]]`)
+	is.Equal(accepted.Load(), int32(1))
+}
+
+func TestStreamQueryStatusError(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusNotFound, "Invalid GET request, no such table 'nope'\n"
+	})
+	q := NewLiveStatusQuery(Table("nope"))
+	var got error
+	for _, err := range StreamOneOff(context.Background(), q, NewLiveStatusConfig(addr)) {
+		got = err
+	}
+	is.True(got != nil)
+	is.True(strings.Contains(got.Error(), "no such table"))
+}
+
+func TestStreamOneOffSeparators(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, "web01\tgw1,gw2\x1edb01\t\x1e"
+	})
+	q := NewLiveStatusQuery(Table("hosts"), "name", "parents").Separators(0x1e, '\t', ',', '|')
+	var rows []Row
+	for row, err := range StreamOneOff(context.Background(), q, NewLiveStatusConfig(addr)) {
+		is.NoErr(err)
+		rows = append(rows, row)
+	}
+	is.Equal(rows, []Row{{"web01", "gw1,gw2"}, {"db01", ""}})
+}
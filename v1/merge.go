@@ -0,0 +1,336 @@
+package livestatus
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Names of the aggregates MergeQuery adds for its own use; Merge removes them.
+const (
+	mergeCountName = "__merge_count"
+	mergeAvgPrefix = "__merge_avg "
+)
+
+// SortKey orders merged rows by one column, ascending unless Desc is set.
+// Cells that are numbers on both sides compare numerically, others as text.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// MergeOptions control how Merge combines per-site results. Livestatus has no
+// ordering or offset, so these are applied to the merged rows.
+type MergeOptions struct {
+	OrderBy []SortKey
+	Offset  int
+	// Limit caps the merged rows. 0 uses the query's Limit for plain queries;
+	// for Stats queries, whose Limit bounds the rows each site aggregates, it
+	// means no cap.
+	Limit int
+}
+
+// mergePlan describes how the results of a query are combined.
+type mergePlan struct {
+	query  *LiveStatusQuery // what is sent to the sites
+	aggs   []StatsAggregate // the caller's aggregates
+	count  int              // index of the hidden count in query's aggregates; -1 for plain queries
+	avgOf  map[int]int      // std aggregate -> hidden average of its column
+	offset int
+	limit  int // -1 if none
+}
+
+func newMergePlan(q *LiveStatusQuery, opts MergeOptions) *mergePlan {
+	p := &mergePlan{
+		query:  q.Clone(),
+		aggs:   q.StatsAggregates(),
+		count:  -1,
+		offset: max(opts.Offset, 0),
+		limit:  -1,
+	}
+	if opts.Limit > 0 {
+		p.limit = opts.Limit
+	}
+	if len(p.aggs) == 0 {
+		if n, ok := q.limit(); ok && opts.Limit <= 0 {
+			p.limit = n
+		}
+		switch {
+		case len(opts.OrderBy) > 0:
+			// Sites return rows in their own order, so the winners can be
+			// anywhere; every site has to send all of its rows.
+			p.query.removeHeader("Limit")
+		case p.limit >= 0:
+			// Each site has to return enough rows for any of them to end up in
+			// the merged window.
+			p.query.Limit(p.offset + p.limit)
+		}
+		return p
+	}
+	// Averages and standard deviations are weighted by the number of rows each
+	// site aggregated, and empty groups must not take part in min and max.
+	p.count = len(p.aggs)
+	p.query.StatsCountExpr(mergeCountName, nil)
+	p.avgOf = make(map[int]int)
+	for i, a := range p.aggs {
+		if a.Func == StatsStd {
+			p.avgOf[i] = len(p.query.statsAggs)
+			p.query.StatsAvg(a.Column).StatsAs(mergeAvgPrefix + a.Column)
+		}
+	}
+	return p
+}
+
+// MergeQuery returns the query to send to each site so that Merge can combine
+// the results of q: Stats queries get hidden aggregates for weighting. Plain
+// queries without opts.OrderBy get a per-site Limit of Offset plus Limit, the
+// most rows a site can contribute to the merged window; with OrderBy any row
+// may win, so their Limit is dropped and every site returns all matching rows.
+// q itself is not modified.
+func MergeQuery(q *LiveStatusQuery, opts MergeOptions) *LiveStatusQuery {
+	return newMergePlan(q, opts).query
+}
+
+// Merge combines the results of the same query on several sites, keyed by
+// site name; the results must come from the query returned by MergeQuery(q, opts).
+//
+// Rows of plain queries are concatenated in site order, each prefixed with the
+// site name (see SiteColumn). Stats queries are merged into one row per
+// combination of group keys across all sites: counts, sums and suminv are
+// added, min and max reduced, avg and avginv weighted by the rows each site
+// aggregated, and std pooled; the result has no site column. opts' ordering,
+// offset and limit are applied last.
+//
+// Results with an error or a non-OK status are reported in MultiResult.Sites
+// and left out; err is only set if every site failed or the rows don't match
+// the query.
+func Merge(q *LiveStatusQuery, results map[string]*Result, opts MergeOptions) (*MultiResult, error) {
+	if q == nil {
+		return nil, fmt.Errorf("query cannot be nil")
+	}
+	answers := make([]siteAnswer, 0, len(results))
+	for site, res := range results {
+		answers = append(answers, siteAnswer{site: site, res: res})
+	}
+	sort.Slice(answers, func(i, j int) bool { return answers[i].site < answers[j].site })
+	return merge(q, answers, opts)
+}
+
+func merge(q *LiveStatusQuery, answers []siteAnswer, opts MergeOptions) (*MultiResult, error) {
+	p := newMergePlan(q, opts)
+	var (
+		out *MultiResult
+		err error
+	)
+	if p.count < 0 {
+		out, err = concat(p.query, answers)
+	} else {
+		out, err = p.mergeStats(answers)
+	}
+	if err != nil {
+		return out, err
+	}
+	if err := out.order(opts.OrderBy); err != nil {
+		return out, err
+	}
+	out.window(p.offset, p.limit)
+	return out, nil
+}
+
+// statsGroup accumulates one group of a merged Stats query.
+type statsGroup struct {
+	keys Row
+	n    float64   // rows aggregated, over all sites
+	vals []float64 // per caller aggregate; weighted sums for avg and std
+	mean []float64 // std only: weighted sum of the site averages
+	set  []bool    // min/max only: a value was seen
+}
+
+// mergeStats merges the Stats rows of all sites by group keys.
+func (p *mergePlan) mergeStats(answers []siteAnswer) (*MultiResult, error) {
+	q := p.query
+	nKeys, want := len(q.columns), len(q.columns)+len(q.statsAggs)
+	out := &MultiResult{Sites: make(map[string]*SiteResult, len(answers)), dec: NewDecoder(q)}
+	var (
+		groups []*statsGroup
+		byKey  = make(map[string]*statsGroup)
+		errs   []error
+	)
+	for _, a := range answers {
+		rows, _, sr := parseAnswer(q, a)
+		out.Sites[sr.Site] = sr
+		if sr.Err == nil {
+			for i, row := range rows {
+				if len(row) != want {
+					sr.Err = fmt.Errorf("stats row %d: got %d values, want %d; use MergeQuery", i, len(row), want)
+					break
+				}
+			}
+		}
+		if sr.Err != nil {
+			errs = append(errs, fmt.Errorf("site %s: %w", sr.Site, sr.Err))
+			continue
+		}
+		for i, row := range rows {
+			vals := make([]float64, len(q.statsAggs))
+			for k := range vals {
+				f, err := cellFloat(row[nKeys+k])
+				if err != nil {
+					return out, fmt.Errorf("site %s: stats row %d, %q: %w", sr.Site, i, q.statsAggs[k].Name, err)
+				}
+				vals[k] = f
+			}
+			keys := make([]string, nKeys)
+			for k := range keys {
+				keys[k] = cellString(row[k])
+			}
+			key := strings.Join(keys, "\x00")
+			g, ok := byKey[key]
+			if !ok {
+				g = &statsGroup{
+					keys: slices.Clone(row[:nKeys]),
+					vals: make([]float64, len(p.aggs)),
+					mean: make([]float64, len(p.aggs)),
+					set:  make([]bool, len(p.aggs)),
+				}
+				byKey[key] = g
+				groups = append(groups, g)
+			}
+			p.add(g, vals)
+		}
+	}
+	if len(errs) == len(answers) {
+		return out, errors.Join(errs...)
+	}
+
+	out.Columns = slices.Clone(q.columns)
+	for _, a := range p.aggs {
+		out.Columns = append(out.Columns, a.Name)
+	}
+	out.dec.columns = out.Columns
+	for _, g := range groups {
+		out.Rows = append(out.Rows, p.row(g))
+	}
+	return out, nil
+}
+
+// add folds one site's values for a group into g.
+func (p *mergePlan) add(g *statsGroup, vals []float64) {
+	n := vals[p.count]
+	g.n += n
+	for i, a := range p.aggs {
+		v := vals[i]
+		switch a.Func {
+		case StatsMin, StatsMax:
+			if n == 0 {
+				continue // Livestatus reports 0 for an empty group
+			}
+			if !g.set[i] || (a.Func == StatsMin && v < g.vals[i]) || (a.Func == StatsMax && v > g.vals[i]) {
+				g.vals[i] = v
+			}
+			g.set[i] = true
+		case StatsAvg, StatsAvgInv:
+			g.vals[i] += v * n
+		case StatsStd:
+			mean := vals[p.avgOf[i]]
+			g.vals[i] += n * (v*v + mean*mean)
+			g.mean[i] += n * mean
+		default: // count, sum, suminv
+			g.vals[i] += v
+		}
+	}
+}
+
+// row renders a merged group.
+func (p *mergePlan) row(g *statsGroup) Row {
+	row := slices.Clone(g.keys)
+	for i, a := range p.aggs {
+		v := g.vals[i]
+		switch a.Func {
+		case StatsAvg, StatsAvgInv:
+			v = ratio(v, g.n)
+		case StatsStd:
+			mean := ratio(g.mean[i], g.n)
+			v = math.Sqrt(max(ratio(v, g.n)-mean*mean, 0))
+		}
+		row = append(row, json.Number(strconv.FormatFloat(v, 'f', -1, 64)))
+	}
+	return row
+}
+
+func ratio(sum, n float64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / n
+}
+
+// order sorts the rows by keys, keeping the merge order among equal rows.
+func (r *MultiResult) order(keys []SortKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	idx := make([]int, len(keys))
+	for k, key := range keys {
+		idx[k] = slices.Index(r.Columns, key.Column)
+		if idx[k] < 0 {
+			return fmt.Errorf("order by %q: no such column in %v", key.Column, r.Columns)
+		}
+	}
+	slices.SortStableFunc(r.Rows, func(a, b Row) int {
+		for k, key := range keys {
+			c := compareCells(cell(a, idx[k]), cell(b, idx[k]))
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// window keeps limit rows starting at offset; a negative limit keeps the rest.
+func (r *MultiResult) window(offset, limit int) {
+	offset = min(offset, len(r.Rows))
+	r.Rows = r.Rows[offset:]
+	if limit >= 0 && limit < len(r.Rows) {
+		r.Rows = r.Rows[:limit]
+	}
+}
+
+func cell(row Row, i int) any {
+	if i < len(row) {
+		return row[i]
+	}
+	return nil
+}
+
+// compareCells compares numerically if both cells are numbers, else as text.
+func compareCells(a, b any) int {
+	fa, errA := cellFloat(a)
+	fb, errB := cellFloat(b)
+	if errA == nil && errB == nil {
+		return cmp.Compare(fa, fb)
+	}
+	return strings.Compare(cellString(a), cellString(b))
+}
+
+// limit returns the value of the query's Limit header, if it has one.
+func (q *LiveStatusQuery) limit() (int, bool) {
+	for _, line := range q.headers {
+		if key, value := headerKV(line); key == "Limit" {
+			n, err := strconv.Atoi(value)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,177 @@
+package livestatus
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func okResult(body string) *Result {
+	return &Result{StatusCode: StatusOK, Data: []byte(body)}
+}
+
+func cellNum(t *testing.T, v any) float64 {
+	t.Helper()
+	f, err := cellFloat(v)
+	if err != nil {
+		t.Fatalf("cell %v: %v", v, err)
+	}
+	return f
+}
+
+func TestMergeStats(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("services"), "state").
+		Stats("has_been_checked", OpEq, "1").StatsAs("checked").
+		StatsSum("latency").StatsMin("latency").StatsMax("latency").StatsAvg("latency").StatsStd("latency").
+		OutputFormat(OutputJSON)
+
+	// Columns: state, checked, sum, min, max, avg, std, hidden count, hidden avg.
+	res, err := Merge(q, map[string]*Result{
+		"a": okResult(`[["0",3,6,1,3,2,0.816496580927726,3,2],["2",0,0,0,0,0,0,0,0]]`),
+		"b": okResult(`[["0",1,6,6,6,6,0,1,6],["2",2,12,5,7,6,1,2,6]]`),
+		"c": {StatusCode: StatusServiceUnavailable, Error: ErrCircuitOpen},
+	}, MergeOptions{})
+	is.NoErr(err)
+	is.Equal(res.Columns, []string{"state", "checked", "sum latency", "min latency", "max latency", "avg latency", "std latency"})
+	is.Equal(len(res.Rows), 2)
+	is.Equal(res.Failed(), []string{"c"})
+
+	ok := res.Rows[0]
+	is.Equal(ok[0], "0")
+	is.Equal(cellNum(t, ok[1]), 4.0)  // counts add
+	is.Equal(cellNum(t, ok[2]), 12.0) // sums add
+	is.Equal(cellNum(t, ok[3]), 1.0)
+	is.Equal(cellNum(t, ok[4]), 6.0)
+	is.Equal(cellNum(t, ok[5]), 3.0)                           // (1+2+3+6)/4, not (2+6)/2
+	is.True(math.Abs(cellNum(t, ok[6])-math.Sqrt(3.5)) < 1e-9) // population std of 1,2,3,6
+
+	crit := res.Rows[1]
+	is.Equal(crit[0], "2")
+	is.Equal(cellNum(t, crit[3]), 5.0) // site a's empty group doesn't count as 0
+	is.Equal(cellNum(t, crit[5]), 6.0)
+	is.Equal(cellNum(t, crit[6]), 1.0)
+
+	var groups []struct {
+		State   int     `livestatus:"state"`
+		Checked int     `livestatus:"checked"`
+		Avg     float64 `livestatus:"avg latency"`
+	}
+	is.NoErr(res.Unmarshal(&groups))
+	is.Equal(groups[0].Checked, 4)
+	is.Equal(groups[1].State, 2)
+	is.Equal(groups[1].Avg, 6.0)
+}
+
+func TestMergeStatsWithoutColumns(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("hosts")).Stats("state", OpEq, "1").OutputFormat(OutputJSON)
+
+	res, err := Merge(q, map[string]*Result{
+		"a": okResult(`[[2,10]]`),
+		"b": okResult(`[[3,20]]`),
+	}, MergeOptions{})
+	is.NoErr(err)
+	is.Equal(res.Rows, []Row{{json.Number("5")}})
+}
+
+func TestMergeNeedsMergeQuery(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("hosts")).StatsAvg("latency").OutputFormat(OutputJSON)
+
+	_, err := Merge(q, map[string]*Result{"a": okResult(`[[2]]`)}, MergeOptions{})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "MergeQuery"))
+}
+
+func TestMergeQuery(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("hosts"), "name").Limit(10)
+
+	is.True(strings.Contains(MergeQuery(q, MergeOptions{Offset: 5}).Build(), "Limit: 15\n"))
+	is.True(strings.Contains(MergeQuery(q, MergeOptions{Offset: 5, Limit: 3}).Build(), "Limit: 8\n"))
+	is.True(strings.Contains(q.Build(), "Limit: 10\n")) // q is left alone
+	// With an order the winners may be any site's last rows.
+	is.True(!strings.Contains(MergeQuery(q, MergeOptions{OrderBy: []SortKey{{Column: "name"}}, Limit: 3}).Build(), "Limit:"))
+
+	stats := NewLiveStatusQuery(Table("hosts")).StatsStd("latency")
+	built := MergeQuery(stats, MergeOptions{}).Build()
+	is.True(strings.Contains(built, "Stats: std latency\nStatsAnd: 0\nStats: avg latency\n"))
+	is.Equal(len(stats.StatsAggregates()), 1)
+}
+
+func TestMergeOrderAndWindow(t *testing.T) {
+	is := is.New(t)
+	q := NewLiveStatusQuery(Table("hosts"), "name", "state").Limit(2).OutputFormat(OutputJSON)
+	opts := MergeOptions{OrderBy: []SortKey{{Column: "state", Desc: true}, {Column: "name"}}, Offset: 1}
+
+	res, err := Merge(q, map[string]*Result{
+		"a": okResult(`[["web03",0],["web01",2]]`),
+		"b": okResult(`[["web10",2],["web02",1]]`),
+	}, opts)
+	is.NoErr(err)
+	// Sorted: web01 2, web10 2, web02 1, web03 0; the query's Limit applies after Offset.
+	is.Equal(len(res.Rows), 2)
+	is.Equal(res.Rows[0][:2], Row{"b", "web10"})
+	is.Equal(res.Rows[1][:2], Row{"b", "web02"})
+
+	// Numbers sort numerically, where text order would put "10" first.
+	res, err = Merge(q, map[string]*Result{"a": okResult(`[["9",0],["10",0]]`)}, MergeOptions{OrderBy: []SortKey{{Column: "name"}}})
+	is.NoErr(err)
+	is.Equal(res.Rows[0][1], "9")
+
+	_, err = Merge(q, map[string]*Result{"a": okResult(`[]`)}, MergeOptions{OrderBy: []SortKey{{Column: "nope"}}})
+	is.True(err != nil)
+}
+
+func TestMultiSiteQueryMerged(t *testing.T) {
+	is := is.New(t)
+	site := func(count, hidden string) *LiveStatusConfig {
+		addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+			if !strings.Contains(req, "StatsAnd: 0\n") {
+				return StatusBadRequest, "hidden count missing"
+			}
+			return StatusOK, "[[" + count + "," + hidden + "]]"
+		})
+		return NewLiveStatusConfig(addr)
+	}
+	m, err := NewMultiSite(Site{Name: "a", Config: site("4", "4")}, Site{Name: "b", Config: site("1", "1")})
+	is.NoErr(err)
+
+	q := NewLiveStatusQuery(Table("hosts")).Stats("state", OpEq, "0").OutputFormat(OutputJSON)
+	res, err := m.QueryMerged(context.Background(), *q, SiteSelector{}, MergeOptions{})
+	is.NoErr(err)
+	is.Equal(res.Columns, []string{"state = 0"})
+	is.Equal(res.Rows, []Row{{json.Number("5")}})
+}
+
+func TestMultiSiteQueryMergedOrderSeesAllRows(t *testing.T) {
+	is := is.New(t)
+	// Sites that honour Limit, like Livestatus does.
+	site := func(rows ...string) *LiveStatusConfig {
+		addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+			n := len(rows)
+			if _, v, ok := strings.Cut(req, "Limit: "); ok {
+				n, _ = strconv.Atoi(v[:strings.IndexByte(v, '\n')])
+			}
+			return StatusOK, "[" + strings.Join(rows[:min(n, len(rows))], ",") + "]"
+		})
+		return NewLiveStatusConfig(addr)
+	}
+	m, err := NewMultiSite(
+		Site{Name: "a", Config: site(`["a1",2]`, `["a2",0]`)},
+		Site{Name: "b", Config: site(`["b1",1]`)},
+	)
+	is.NoErr(err)
+
+	q := NewLiveStatusQuery(Table("hosts"), "name", "state").OutputFormat(OutputJSON)
+	res, err := m.QueryMerged(context.Background(), *q, SiteSelector{}, MergeOptions{OrderBy: []SortKey{{Column: "state"}}, Limit: 1})
+	is.NoErr(err)
+	is.Equal(len(res.Rows), 1)
+	is.Equal(res.Rows[0][:2], Row{"a", "a2"}) // a's second row
+}
@@ -25,6 +25,7 @@ type Metrics struct {
 
 	// Connection lifecycle
 	reconnectsTotal *prometheus.CounterVec
+	retriesTotal    *prometheus.CounterVec
 
 	// Connectivity metrics (mirrors http exporter style)
 	clientConnected        *prometheus.GaugeVec
@@ -32,6 +33,7 @@ type Metrics struct {
 	clientConnDurationSecs *prometheus.GaugeVec
 	connectionDialsTotal   *prometheus.CounterVec
 	connectionErrorsTotal  *prometheus.CounterVec
+	circuitState           *prometheus.GaugeVec
 	siteName               string
 }
 
@@ -90,9 +92,9 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 				Namespace: "livestatus",
 				Subsystem: "actor",
 				Name:      "in_flight",
-				Help:      "Number of items currently being processed by the single worker",
+				Help:      "Number of items currently being processed, per pooled connection",
 			},
-			[]string{"site"},
+			[]string{"site", "conn"},
 		),
 		processingSeconds: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -144,6 +146,15 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 			},
 			[]string{"site", "reason"},
 		),
+		retriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "livestatus",
+				Subsystem: "actor",
+				Name:      "retries_total",
+				Help:      "Requests retried after a failed attempt, by failure class",
+			},
+			[]string{"site", "reason"},
+		),
 
 		// Connectivity metrics
 		clientConnected: prometheus.NewGaugeVec(
@@ -151,9 +162,9 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 				Namespace: "livestatus",
 				Subsystem: "actor",
 				Name:      "client_connected",
-				Help:      "Whether the actor's TCP client is currently connected (1) or not (0)",
+				Help:      "Whether a pooled connection is currently connected (1) or not (0)",
 			},
-			[]string{"site"}, // Variable label for actor/site
+			[]string{"site", "conn"}, // Variable label for actor/site
 		),
 		clientConnUptimeSecs: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -162,7 +173,7 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 				Name:      "client_connection_uptime_seconds",
 				Help:      "How long the current connection has been up (seconds)",
 			},
-			[]string{"site"}, // Variable label for actor/site
+			[]string{"site", "conn"}, // Variable label for actor/site
 		),
 		clientConnDurationSecs: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -171,7 +182,7 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 				Name:      "client_connection_duration_seconds",
 				Help:      "Duration of the most recently closed connection (seconds)",
 			},
-			[]string{"site"}, // Variable label for actor/site
+			[]string{"site", "conn"}, // Variable label for actor/site
 		),
 		connectionDialsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -191,6 +202,15 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 			},
 			[]string{"site"}, // Variable label for actor/site
 		),
+		circuitState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "livestatus",
+				Subsystem: "actor",
+				Name:      "circuit_state",
+				Help:      "State of the site's circuit breaker: 0 closed, 1 open, 2 half-open",
+			},
+			[]string{"site"}, // Variable label for actor/site
+		),
 	}
 
 	// Register all metrics, reusing existing collectors if already registered
@@ -264,6 +284,13 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 			panic(err)
 		}
 	}
+	if err := reg.Register(m.retriesTotal); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.retriesTotal = are.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			panic(err)
+		}
+	}
 	if err := reg.Register(m.clientConnected); err != nil {
 		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
 			m.clientConnected = are.ExistingCollector.(*prometheus.GaugeVec)
@@ -299,6 +326,13 @@ func NewMetrics(reg prometheus.Registerer, siteName string) *Metrics {
 			panic(err)
 		}
 	}
+	if err := reg.Register(m.circuitState); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.circuitState = are.ExistingCollector.(*prometheus.GaugeVec)
+		} else {
+			panic(err)
+		}
+	}
 
 	return m
 }
@@ -323,14 +357,24 @@ func (m *Metrics) IncrementDropped(reason string) {
 	m.droppedTotal.WithLabelValues(m.siteName, reason).Inc()
 }
 
-// IncrementInFlight increments the in-flight gauge
+// IncrementInFlight increments the in-flight gauge of the first connection
 func (m *Metrics) IncrementInFlight() {
-	m.inFlight.WithLabelValues(m.siteName).Inc()
+	m.IncrementInFlightForConn("0")
 }
 
-// DecrementInFlight decrements the in-flight gauge
+// DecrementInFlight decrements the in-flight gauge of the first connection
 func (m *Metrics) DecrementInFlight() {
-	m.inFlight.WithLabelValues(m.siteName).Dec()
+	m.DecrementInFlightForConn("0")
+}
+
+// IncrementInFlightForConn increments the in-flight gauge of a pooled connection
+func (m *Metrics) IncrementInFlightForConn(conn string) {
+	m.inFlight.WithLabelValues(m.siteName, conn).Inc()
+}
+
+// DecrementInFlightForConn decrements the in-flight gauge of a pooled connection
+func (m *Metrics) DecrementInFlightForConn(conn string) {
+	m.inFlight.WithLabelValues(m.siteName, conn).Dec()
 }
 
 // ObserveProcessingSeconds observes processing duration
@@ -358,18 +402,37 @@ func (m *Metrics) IncrementReconnects(reason string) {
 	m.reconnectsTotal.WithLabelValues(m.siteName, reason).Inc()
 }
 
-// Connectivity helpers
+// IncrementRetries increments the retries counter with the failure class
+func (m *Metrics) IncrementRetries(reason string) {
+	m.retriesTotal.WithLabelValues(m.siteName, reason).Inc()
+}
+
+// Connectivity helpers; without ForConn they report the first connection.
 
 func (m *Metrics) SetClientConnected(on int) {
-	m.clientConnected.WithLabelValues(m.siteName).Set(float64(on))
+	m.SetClientConnectedForConn("0", on)
 }
 
 func (m *Metrics) SetClientConnUptime(seconds float64) {
-	m.clientConnUptimeSecs.WithLabelValues(m.siteName).Set(seconds)
+	m.SetClientConnUptimeForConn("0", seconds)
 }
 
 func (m *Metrics) SetClientConnDuration(seconds float64) {
-	m.clientConnDurationSecs.WithLabelValues(m.siteName).Set(seconds)
+	m.SetClientConnDurationForConn("0", seconds)
+}
+
+// Per-connection variants; conn is the index of the pooled connection.
+
+func (m *Metrics) SetClientConnectedForConn(conn string, on int) {
+	m.clientConnected.WithLabelValues(m.siteName, conn).Set(float64(on))
+}
+
+func (m *Metrics) SetClientConnUptimeForConn(conn string, seconds float64) {
+	m.clientConnUptimeSecs.WithLabelValues(m.siteName, conn).Set(seconds)
+}
+
+func (m *Metrics) SetClientConnDurationForConn(conn string, seconds float64) {
+	m.clientConnDurationSecs.WithLabelValues(m.siteName, conn).Set(seconds)
 }
 
 func (m *Metrics) IncrementConnectionDials() {
@@ -379,3 +442,8 @@ func (m *Metrics) IncrementConnectionDials() {
 func (m *Metrics) IncrementConnectionErrors() {
 	m.connectionErrorsTotal.WithLabelValues(m.siteName).Inc()
 }
+
+// SetCircuitState sets the circuit breaker gauge (see BreakerState)
+func (m *Metrics) SetCircuitState(state int) {
+	m.circuitState.WithLabelValues(m.siteName).Set(float64(state))
+}
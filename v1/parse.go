@@ -0,0 +1,306 @@
+package livestatus
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseError describes an LQL syntax error with a 1-based line and column.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("lql:%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// knownOps lists the filter operators ParseLQL accepts.
+var knownOps = []Op{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe, OpRe, OpReIC, OpEqIC, OpNRe, OpNReIC, OpNeIC}
+
+// knownOutputFormats lists the valid OutputFormat values.
+var knownOutputFormats = []OutputFormat{OutputCSV, OutputJSON, OutputPY, OutputPython3, OutputWrappedJSON}
+
+// knownWaitTriggers lists the valid WaitTrigger values.
+var knownWaitTriggers = []string{"check", "state", "log", "downtime", "comment", "command", "program", "all"}
+
+// knownStatsFuncs lists the aggregate functions accepted in "Stats: <func> <column>".
+var knownStatsFuncs = []StatsFunc{StatsSum, StatsMin, StatsMax, StatsAvg, StatsStd, StatsSumInv, StatsAvgInv}
+
+// ParseLQL parses a GET request written in the Livestatus Query Language into a
+// LiveStatusQuery. The result can be inspected, validated and sent through the
+// actor; Build() on it yields an equivalent request (headers may be reordered,
+// but the relative order of Filter, Stats and WaitCondition lines is kept).
+//
+// Parsing stops at the first blank line; anything but whitespace after it is an error.
+func ParseLQL(text string) (*LiveStatusQuery, error) {
+	lines := strings.Split(text, "\n")
+	p := &lqlParser{}
+	for i, raw := range lines {
+		p.line = i + 1
+		line := strings.TrimSuffix(raw, "\r")
+		if p.q == nil {
+			if err := p.parseGet(line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if line == "" {
+			// End of request: only whitespace may follow.
+			for j, rest := range lines[i+1:] {
+				if k := strings.IndexFunc(rest, func(r rune) bool { return !strings.ContainsRune(" \t\r", r) }); k >= 0 {
+					return nil, &ParseError{Line: p.line + j + 1, Column: k + 1, Msg: "unexpected content after end of request"}
+				}
+			}
+			break
+		}
+		if err := p.parseHeader(line); err != nil {
+			return nil, err
+		}
+	}
+	if p.q == nil {
+		return nil, &ParseError{Line: 1, Column: 1, Msg: "empty request"}
+	}
+	return p.q, nil
+}
+
+type lqlParser struct {
+	q    *LiveStatusQuery
+	line int
+}
+
+func (p *lqlParser) errorf(col int, format string, args ...any) error {
+	return &ParseError{Line: p.line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *lqlParser) parseGet(line string) error {
+	rest, ok := strings.CutPrefix(line, "GET ")
+	if !ok {
+		if strings.HasPrefix(line, "COMMAND ") {
+			return p.errorf(1, "COMMAND requests are not queries")
+		}
+		return p.errorf(1, "request must start with \"GET <table>\"")
+	}
+	table := strings.TrimSpace(rest)
+	if table == "" || strings.ContainsAny(table, " \t") {
+		return p.errorf(5, "invalid table name %q", table)
+	}
+	p.q = NewLiveStatusQuery(Table(table))
+	return nil
+}
+
+func (p *lqlParser) parseHeader(line string) error {
+	idx := strings.IndexByte(line, ':')
+	if idx <= 0 {
+		return p.errorf(1, "expected \"Header: value\"")
+	}
+	key := line[:idx]
+	value := line[idx+1:]
+	vcol := idx + 2 // column of the first value character
+	for strings.HasPrefix(value, " ") {
+		value = value[1:]
+		vcol++
+	}
+	q := p.q
+
+	switch key {
+	case "Columns":
+		cols := strings.Fields(value)
+		if len(cols) == 0 {
+			return p.errorf(vcol, "Columns needs at least one column")
+		}
+		q.AddColumns(cols...)
+	case "Filter":
+		col, op, val, err := p.parseCondition(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.Filter(col, op, val)
+	case "And", "Or":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		if key == "And" {
+			q.And(n)
+		} else {
+			q.Or(n)
+		}
+	case "Negate":
+		if value != "" {
+			return p.errorf(vcol, "Negate takes no value")
+		}
+		q.Negate()
+	case "Stats":
+		return p.parseStats(value, vcol)
+	case "StatsAnd", "StatsOr":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		if key == "StatsAnd" {
+			q.StatsAnd(n)
+		} else {
+			q.StatsOr(n)
+		}
+	case "StatsNegate":
+		if value != "" {
+			return p.errorf(vcol, "StatsNegate takes no value")
+		}
+		q.StatsNegate()
+	case "WaitCondition":
+		if _, _, _, err := p.parseCondition(value, vcol); err != nil {
+			return err
+		}
+		q.WaitCondition(value)
+	case "WaitConditionAnd", "WaitConditionOr":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.Header(key, strconv.Itoa(n))
+	case "WaitConditionNegate":
+		if value != "" {
+			return p.errorf(vcol, "WaitConditionNegate takes no value")
+		}
+		q.Header(key, "")
+	case "WaitTrigger":
+		if !slices.Contains(knownWaitTriggers, value) {
+			return p.errorf(vcol, "unknown WaitTrigger %q (want one of %s)", value, strings.Join(knownWaitTriggers, ", "))
+		}
+		q.WaitTrigger(value)
+	case "WaitObject":
+		if value == "" {
+			return p.errorf(vcol, "WaitObject needs a value")
+		}
+		q.WaitObject(value)
+	case "WaitTimeout":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.WaitTimeout(n)
+	case "Limit":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.Limit(n)
+	case "Localtime":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return p.errorf(vcol, "invalid Localtime %q", value)
+		}
+		q.Localtime(n)
+	case "OutputFormat":
+		if q.outputFormat != "" {
+			return p.errorf(1, "duplicate OutputFormat header")
+		}
+		if !slices.Contains(knownOutputFormats, OutputFormat(value)) {
+			return p.errorf(vcol, "unknown OutputFormat %q", value)
+		}
+		q.OutputFormat(OutputFormat(value))
+	case "ColumnHeaders":
+		on, err := p.parseOnOff(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.ColumnHeaders(on)
+	case "KeepAlive":
+		on, err := p.parseOnOff(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.KeepAlive(on)
+	case "ResponseHeader":
+		switch value {
+		case "fixed16":
+			q.ResponseHeaderFixed16()
+		case "off":
+			q.ResponseHeaderOff()
+		default:
+			return p.errorf(vcol, "unknown ResponseHeader %q (want fixed16 or off)", value)
+		}
+	case "AuthUser":
+		if value == "" {
+			return p.errorf(vcol, "AuthUser needs a value")
+		}
+		q.AuthUser(value)
+	case "Timelimit":
+		n, err := p.parseCount(value, vcol)
+		if err != nil {
+			return err
+		}
+		q.Timelimit(n)
+	case "Separators":
+		seps, err := parseSeparators(value)
+		if err != nil {
+			return p.errorf(vcol, "%v", err)
+		}
+		q.Separators(seps.row, seps.field, seps.list, seps.hostService)
+	default:
+		return p.errorf(1, "unknown header %q", key)
+	}
+	return nil
+}
+
+// parseCondition splits "column op value"; the value may be empty or contain spaces.
+func (p *lqlParser) parseCondition(s string, col int) (string, Op, string, error) {
+	column, rest, ok := strings.Cut(s, " ")
+	if column == "" {
+		return "", "", "", p.errorf(col, "missing column name")
+	}
+	if !ok {
+		return "", "", "", p.errorf(col+len(column), "missing operator after %q", column)
+	}
+	opCol := col + len(column) + 1
+	for strings.HasPrefix(rest, " ") {
+		rest = rest[1:]
+		opCol++
+	}
+	opStr, value, _ := strings.Cut(rest, " ")
+	op := Op(opStr)
+	if !slices.Contains(knownOps, op) {
+		return "", "", "", p.errorf(opCol, "unknown operator %q", opStr)
+	}
+	return column, op, value, nil
+}
+
+func (p *lqlParser) parseStats(value string, col int) error {
+	fn, column, _ := strings.Cut(value, " ")
+	if slices.Contains(knownStatsFuncs, StatsFunc(fn)) {
+		column = strings.TrimSpace(column)
+		if column == "" || strings.Contains(column, " ") {
+			return p.errorf(col+len(fn), "Stats %s needs exactly one column", fn)
+		}
+		p.q.statsAggregate(StatsFunc(fn), column)
+		return nil
+	}
+	c, op, v, err := p.parseCondition(value, col)
+	if err != nil {
+		return err
+	}
+	p.q.Stats(c, op, v)
+	return nil
+}
+
+func (p *lqlParser) parseCount(s string, col int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, p.errorf(col, "expected a non-negative integer, got %q", s)
+	}
+	return n, nil
+}
+
+func (p *lqlParser) parseOnOff(s string, col int) (bool, error) {
+	switch s {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, p.errorf(col, "expected on or off, got %q", s)
+}
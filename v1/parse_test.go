@@ -0,0 +1,80 @@
+package livestatus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseLQLRoundTrip(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("services"), "host_name", "description", "state").
+		FilterExpr(And(Eq("state", "2"), Or(Re("host_name", "^web"), Not(Eq("acknowledged", "1"))))).
+		Filter("parents", OpEq, "").
+		Stats("state", OpEq, "0").
+		StatsAvg("latency").
+		WaitTrigger("state").
+		WaitObject("web01;HTTP check").
+		WaitConditionExpr(Or(Eq("state", "0"), Eq("state", "1"))).
+		WaitTimeout(500).
+		ColumnHeaders(true).
+		Limit(10).
+		Localtime(1724000000).
+		AuthUser("alice").
+		Timelimit(30).
+		Separators(10, '\t', ',', '|').
+		KeepAlive(false).
+		OutputFormat(OutputJSON)
+
+	parsed, err := ParseLQL(q.Build())
+	is.NoErr(err)
+	is.Equal(parsed.Build(), q.Build())
+	is.Equal(parsed.StatsAggregates(), q.StatsAggregates())
+}
+
+func TestParseLQLHandwritten(t *testing.T) {
+	is := is.New(t)
+
+	text := "GET hosts\r\nColumns: name  state\r\nFilter: state != 0\r\nOutputFormat: csv\r\n\r\n\n"
+	q, err := ParseLQL(text)
+	is.NoErr(err)
+	is.Equal(q.Build(), "GET hosts\nColumns: name state\nFilter: state != 0\nOutputFormat: csv\n")
+}
+
+func TestParseLQLErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		text      string
+		line, col int
+	}{
+		{"empty", "", 1, 1},
+		{"no get", "Columns: name\n", 1, 1},
+		{"command", "COMMAND [1] NOP\n", 1, 1},
+		{"bad table", "GET \n", 1, 5},
+		{"no colon", "GET hosts\nColumns name\n", 2, 1},
+		{"unknown header", "GET hosts\nFrobnicate: 1\n", 2, 1},
+		{"bad op", "GET hosts\nFilter: state =! 1\n", 2, 15},
+		{"missing op", "GET hosts\nFilter: state\n", 2, 14},
+		{"bad count", "GET hosts\nFilter: state = 1\nAnd: x\n", 3, 6},
+		{"bad trigger", "GET hosts\nWaitTrigger: sometimes\n", 2, 14},
+		{"bad format", "GET hosts\nOutputFormat: xml\n", 2, 15},
+		{"dup format", "GET hosts\nOutputFormat: json\nOutputFormat: csv\n", 3, 1},
+		{"bad onoff", "GET hosts\nColumnHeaders: yes\n", 2, 16},
+		{"bad stats", "GET hosts\nStats: sum\n", 2, 11},
+		{"bad separators", "GET hosts\nSeparators: 10 10 44 124\n", 2, 13},
+		{"bad timelimit", "GET hosts\nTimelimit: -1\n", 2, 12},
+		{"trailing", "GET hosts\n\nGET services\n", 3, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			_, err := ParseLQL(tc.text)
+			var pe *ParseError
+			is.True(errors.As(err, &pe))
+			is.Equal(pe.Line, tc.line)
+			is.Equal(pe.Column, tc.col)
+		})
+	}
+}
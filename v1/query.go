@@ -13,9 +13,11 @@ type Table string
 type OutputFormat string
 
 const (
-	OutputCSV  OutputFormat = "csv"
-	OutputJSON OutputFormat = "json"
-	OutputPY   OutputFormat = "python"
+	OutputCSV         OutputFormat = "csv"
+	OutputJSON        OutputFormat = "json"
+	OutputPY          OutputFormat = "python"
+	OutputPython3     OutputFormat = "python3"
+	OutputWrappedJSON OutputFormat = "wrapped_json" // {"data": [...], "total_count": N}
 )
 
 // Op enumerates filter operators. Not exhaustive; add as needed.
@@ -30,6 +32,11 @@ const (
 	OpGe   Op = ">="
 	OpRe   Op = "~"  // regex
 	OpReIC Op = "~~" // case-insensitive regex
+	OpEqIC Op = "=~" // case-insensitive equality
+	// Negated forms of the string operators.
+	OpNRe   Op = "!~"
+	OpNReIC Op = "!~~"
+	OpNeIC  Op = "!=~"
 	// List operators exist in Livestatus but we'll add helpers when needed.
 )
 
@@ -38,6 +45,8 @@ type LiveStatusQuery struct {
 	table        Table
 	columns      []string
 	filters      []string // each entry is a full "Filter: ..." line or And:/Or:/Negate:
+	stats        []string // each entry is a full "Stats: ..." line or StatsAnd:/StatsOr:/StatsNegate:
+	statsAggs    []StatsAggregate
 	headers      []string // other headers (Limit, Wait*, ResponseHeader, KeepAlive, etc.)
 	outputFormat OutputFormat
 	columnHdrs   *bool // nil=unset; true/false -> ColumnHeaders: on/off
@@ -62,7 +71,8 @@ func (q *LiveStatusQuery) AddColumns(cols ...string) *LiveStatusQuery {
 	return q
 }
 
-// OutputFormat sets desired format (csv/json/python). Defaults to csv if unset.
+// OutputFormat sets the desired format (csv, json, python, python3,
+// wrapped_json). Defaults to csv if unset.
 func (q *LiveStatusQuery) OutputFormat(fmt OutputFormat) *LiveStatusQuery {
 	q.outputFormat = fmt
 	return q
@@ -107,6 +117,13 @@ func (q *LiveStatusQuery) FilterRegexI(column, pattern string) *LiveStatusQuery
 	return q.Filter(column, OpReIC, pattern)
 }
 
+// FilterExpr compiles an expression tree into Filter:/And:/Or:/Negate: lines
+// and appends them, so the stack counts are always right.
+func (q *LiveStatusQuery) FilterExpr(e Expr) *LiveStatusQuery {
+	q.filters = append(q.filters, compileExpr(e, filterDialect)...)
+	return q
+}
+
 // Boolean composition (the order matters; these map 1:1 to LQL):
 // After pushing N previous filters, calling Or(2) will add "Or: 2" to glue last two filters.
 // Same for And(). Negate() flips the last filter or expression.
@@ -123,56 +140,72 @@ func (q *LiveStatusQuery) Negate() *LiveStatusQuery {
 	return q
 }
 
-// Limit appends "Limit: N".
+// Limit sets "Limit: N", replacing any earlier limit.
 func (q *LiveStatusQuery) Limit(n int) *LiveStatusQuery {
-	q.headers = append(q.headers, fmt.Sprintf("Limit: %d", n))
-	return q
+	return q.setHeader("Limit", fmt.Sprintf("Limit: %d", n))
+}
+
+// AuthUser restricts the result to objects the given contact may see.
+func (q *LiveStatusQuery) AuthUser(user string) *LiveStatusQuery {
+	return q.setHeader("AuthUser", "AuthUser: "+safeToken(user))
+}
+
+// Timelimit aborts the query on the server after the given number of seconds.
+func (q *LiveStatusQuery) Timelimit(seconds int) *LiveStatusQuery {
+	return q.setHeader("Timelimit", fmt.Sprintf("Timelimit: %d", seconds))
+}
+
+// Separators sets the csv separators: between rows, between fields, between
+// list elements and between host and service in lists of services.
+// The Livestatus defaults are '\n', ';', ',' and '|'.
+func (q *LiveStatusQuery) Separators(row, field, list, hostService byte) *LiveStatusQuery {
+	return q.setHeader("Separators", fmt.Sprintf("Separators: %d %d %d %d", row, field, list, hostService))
 }
 
 // Wait* helpers (optional long-polling patterns):
 func (q *LiveStatusQuery) WaitObject(object string) *LiveStatusQuery {
-	q.headers = append(q.headers, fmt.Sprintf("WaitObject: %s", safeValue(object)))
-	return q
+	return q.setHeader("WaitObject", fmt.Sprintf("WaitObject: %s", safeValue(object)))
 }
 func (q *LiveStatusQuery) WaitTrigger(trigger string) *LiveStatusQuery {
 	// allowed: check|state|log|downtime|comment|command|program|all
-	q.headers = append(q.headers, fmt.Sprintf("WaitTrigger: %s", safeToken(trigger)))
-	return q
+	return q.setHeader("WaitTrigger", fmt.Sprintf("WaitTrigger: %s", safeToken(trigger)))
 }
 func (q *LiveStatusQuery) WaitCondition(cond string) *LiveStatusQuery {
 	q.headers = append(q.headers, fmt.Sprintf("WaitCondition: %s", safeValue(cond)))
 	return q
 }
-func (q *LiveStatusQuery) WaitTimeout(ms int) *LiveStatusQuery {
-	q.headers = append(q.headers, fmt.Sprintf("WaitTimeout: %d", ms))
+
+// WaitConditionExpr compiles an expression tree into WaitCondition:/WaitConditionAnd:/
+// WaitConditionOr:/WaitConditionNegate: lines.
+func (q *LiveStatusQuery) WaitConditionExpr(e Expr) *LiveStatusQuery {
+	q.headers = append(q.headers, compileExpr(e, waitDialect)...)
 	return q
 }
+func (q *LiveStatusQuery) WaitTimeout(ms int) *LiveStatusQuery {
+	return q.setHeader("WaitTimeout", fmt.Sprintf("WaitTimeout: %d", ms))
+}
 
 // Connection/response framing:
 func (q *LiveStatusQuery) KeepAlive(on bool) *LiveStatusQuery {
 	if on {
-		q.headers = append(q.headers, "KeepAlive: on")
-	} else {
-		q.headers = append(q.headers, "KeepAlive: off")
+		return q.setHeader("KeepAlive", "KeepAlive: on")
 	}
-	return q
+	return q.setHeader("KeepAlive", "KeepAlive: off")
 }
 func (q *LiveStatusQuery) ResponseHeaderFixed16() *LiveStatusQuery {
-	q.headers = append(q.headers, "ResponseHeader: fixed16")
-	return q
+	return q.setHeader("ResponseHeader", "ResponseHeader: fixed16")
 }
 func (q *LiveStatusQuery) ResponseHeaderOff() *LiveStatusQuery {
-	q.headers = append(q.headers, "ResponseHeader: off")
-	return q
+	return q.setHeader("ResponseHeader", "ResponseHeader: off")
 }
 
 // Localtime header (unix seconds).
 func (q *LiveStatusQuery) Localtime(ts int64) *LiveStatusQuery {
-	q.headers = append(q.headers, fmt.Sprintf("Localtime: %d", ts))
-	return q
+	return q.setHeader("Localtime", fmt.Sprintf("Localtime: %d", ts))
 }
 
-// Stats*, Group*, and any advanced headers can be injected via Header():
+// Group* and any advanced headers can be injected via Header(). Prefer the Stats
+// builder methods over Header("Stats", ...) so DecodeStats knows the aggregates.
 func (q *LiveStatusQuery) Header(key, value string) *LiveStatusQuery {
 	key = strings.TrimSpace(strings.TrimSuffix(key, ":"))
 	if key == "" {
@@ -194,6 +227,7 @@ func (q *LiveStatusQuery) Build() string {
 		lines = append(lines, "Columns: "+strings.Join(safeTokens(q.columns), " "))
 	}
 	lines = append(lines, q.filters...)
+	lines = append(lines, q.stats...)
 	// ColumnHeaders must appear before OutputFormat ideally, but order is not strict.
 	if q.columnHdrs != nil {
 		if *q.columnHdrs {
@@ -211,8 +245,50 @@ func (q *LiveStatusQuery) Build() string {
 	return strings.Join(lines, "\n")
 }
 
+// Clone returns a deep copy, so the copy can be modified without affecting q.
+func (q *LiveStatusQuery) Clone() *LiveStatusQuery {
+	c := *q
+	c.columns = slices.Clone(q.columns)
+	c.filters = slices.Clone(q.filters)
+	c.stats = slices.Clone(q.stats)
+	c.statsAggs = slices.Clone(q.statsAggs)
+	c.headers = slices.Clone(q.headers)
+	if q.columnHdrs != nil {
+		on := *q.columnHdrs
+		c.columnHdrs = &on
+	}
+	return &c
+}
+
 // --- helpers ---
 
+// setHeader replaces every existing line for key with line, so headers that may
+// only appear once do. It never writes into the old backing array, which may be
+// shared with value copies of the query.
+func (q *LiveStatusQuery) setHeader(key, line string) *LiveStatusQuery {
+	headers := make([]string, 0, len(q.headers)+1)
+	for _, h := range q.headers {
+		if k, _ := headerKV(h); k != key {
+			headers = append(headers, h)
+		}
+	}
+	q.headers = append(headers, line)
+	return q
+}
+
+// removeHeader drops every line for key, again without touching the old
+// backing array.
+func (q *LiveStatusQuery) removeHeader(key string) *LiveStatusQuery {
+	headers := make([]string, 0, len(q.headers))
+	for _, h := range q.headers {
+		if k, _ := headerKV(h); k != key {
+			headers = append(headers, h)
+		}
+	}
+	q.headers = headers
+	return q
+}
+
 // safeToken removes CR/LF and trims; tokens should not contain spaces in headers like keys or triggers.
 func safeToken(s string) string {
 	s = strings.ReplaceAll(s, "\r", " ")
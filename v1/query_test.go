@@ -1,6 +1,7 @@
 package livestatus
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -102,3 +103,63 @@ func TestHeaderInjectionAndSanitization(t *testing.T) {
 	is.Equal(lines[5], "WeirdEmpty:")
 	is.Equal(lines[6], "OutputFormat: csv")
 }
+
+func TestTypedHeaders(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("hosts"), "name").
+		AuthUser("alice\n").
+		Timelimit(10).
+		Separators(10, '\t', ',', '|').
+		Limit(5).
+		Limit(10). // singular headers replace earlier values
+		OutputFormat(OutputWrappedJSON)
+
+	want := "GET hosts\nColumns: name\nAuthUser: alice\nTimelimit: 10\nSeparators: 10 9 44 124\nLimit: 10\nOutputFormat: wrapped_json\n"
+	is.Equal(q.Build(), want)
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("hosts"), "name").FilterEqual("state", "1").ColumnHeaders(true)
+	c := q.Clone().FilterEqual("state", "2").And(2).ColumnHeaders(false).KeepAlive(true)
+	c.Columns("address")
+
+	is.Equal(q.Build(), "GET hosts\nColumns: name\nFilter: state = 1\nColumnHeaders: on\n")
+	is.True(strings.Contains(c.Build(), "And: 2"))
+}
+
+func TestValidateQuery(t *testing.T) {
+	is := is.New(t)
+
+	ok := NewLiveStatusQuery(Table("services"), "host_name").
+		FilterEqual("state", "2").FilterEqual("state", "3").Or(2).
+		WaitTrigger("state").Limit(0)
+	got, err := ok.BuildChecked()
+	is.NoErr(err)
+	is.Equal(got, ok.Build())
+
+	bad := NewLiveStatusQuery(Table("services"), "host_name").
+		FilterEqual("state", "2").
+		And(3).
+		Limit(-1).
+		WaitTrigger("sometimes").
+		Header("OutputFormat", "json").
+		OutputFormat(OutputCSV).
+		Header("Separators", "10 10 44 124")
+	_, err = bad.BuildChecked()
+	is.True(errors.Is(err, ErrInvalidQuery))
+	var ve *ValidationError
+	is.True(errors.As(err, &ve))
+	is.Equal(ve.Problems, []string{
+		`Limit: expected a non-negative integer, got "-1"`,
+		`unknown WaitTrigger "sometimes" (want one of check, state, log, downtime, comment, command, program, all)`,
+		`Separators: row and field separator are both 10`,
+		`OutputFormat given 2 times`,
+		`And: 3 exceeds the 1 entries on the Filter stack`,
+	})
+
+	is.True(NewLiveStatusQuery(Table("hosts")).OutputFormat("xml").Validate() != nil)
+	is.True(NewLiveStatusQuery(Table("hosts")).Negate().Validate() != nil)
+}
@@ -0,0 +1,546 @@
+package livestatus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Row is a single response row as decoded from the wire.
+//
+// Cell values depend on the OutputFormat: csv yields strings only, while json and
+// python yield string, json.Number, bool, nil, []any and map[string]any.
+type Row []any
+
+// separators holds the CSV separators (see the Livestatus "Separators:" header).
+type separators struct {
+	row         byte
+	field       byte
+	list        byte
+	hostService byte
+}
+
+// defaultSeparators are the Livestatus defaults: "Separators: 10 59 44 124".
+var defaultSeparators = separators{row: '\n', field: ';', list: ',', hostService: '|'}
+
+// separators returns the csv separators requested by q's Separators header,
+// or the defaults if it has none (or an invalid one, which Validate reports).
+func (q *LiveStatusQuery) separators() separators {
+	for _, line := range q.headers {
+		if key, value := headerKV(line); key == "Separators" {
+			if seps, err := parseSeparators(value); err == nil {
+				return seps
+			}
+		}
+	}
+	return defaultSeparators
+}
+
+// rowReader yields rows one at a time; Next returns io.EOF after the last row.
+type rowReader interface {
+	Next() (Row, error)
+}
+
+// newRowReader returns a reader decoding rows of the given format from r.
+func newRowReader(format OutputFormat, r io.Reader, seps separators) (rowReader, error) {
+	switch format {
+	case "", OutputCSV:
+		return &csvRowReader{r: bufio.NewReader(r), seps: seps}, nil
+	case OutputJSON:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		return &jsonRowReader{dec: dec}, nil
+	case OutputWrappedJSON:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		return &wrappedJSONRowReader{dec: dec}, nil
+	case OutputPY, OutputPython3:
+		// python3 differs only in dropping the u prefix on strings, which the parser accepts either way.
+		return &pyRowReader{p: &pyParser{r: bufio.NewReader(r)}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// parseRows decodes a fully buffered response body.
+func parseRows(format OutputFormat, data []byte, seps separators) ([]Row, error) {
+	rr, err := newRowReader(format, bytes.NewReader(data), seps)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	for {
+		row, err := rr.Next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ---------- csv ----------
+
+type csvRowReader struct {
+	r    *bufio.Reader
+	seps separators
+}
+
+func (c *csvRowReader) Next() (Row, error) {
+	line, err := c.r.ReadBytes(c.seps.row)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(line) == 0 && err == io.EOF {
+		return nil, io.EOF
+	}
+	line = bytes.TrimSuffix(line, []byte{c.seps.row})
+	fields := bytes.Split(line, []byte{c.seps.field})
+	row := make(Row, len(fields))
+	for i, f := range fields {
+		row[i] = string(f)
+	}
+	return row, nil
+}
+
+// ---------- json ----------
+
+type jsonRowReader struct {
+	dec     *json.Decoder
+	started bool
+}
+
+func (j *jsonRowReader) Next() (Row, error) {
+	if !j.started {
+		tok, err := j.dec.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return nil, fmt.Errorf("json: expected '[' at start of response, got %v", tok)
+		}
+		j.started = true
+	}
+	if !j.dec.More() {
+		// consume the closing bracket
+		if _, err := j.dec.Token(); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	var row []any
+	if err := j.dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return Row(row), nil
+}
+
+// ---------- wrapped_json ----------
+
+// wrappedJSONRowReader reads {"columns": [...], "data": [[...], ...], "total_count": N}.
+// The columns entry, sent with ColumnHeaders: on, is yielded as the first row
+// when it precedes data; other keys are skipped.
+type wrappedJSONRowReader struct {
+	dec     *json.Decoder
+	started bool
+	data    *jsonRowReader // non-nil while inside the data array
+}
+
+func (w *wrappedJSONRowReader) Next() (Row, error) {
+	if !w.started {
+		tok, err := w.dec.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '{' {
+			return nil, fmt.Errorf("wrapped_json: expected '{' at start of response, got %v", tok)
+		}
+		w.started = true
+	}
+	for {
+		if w.data != nil {
+			row, err := w.data.Next()
+			if err != io.EOF {
+				return row, err
+			}
+			w.data = nil
+		}
+		if !w.dec.More() {
+			if _, err := w.dec.Token(); err != nil && err != io.EOF {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		tok, err := w.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("wrapped_json: %w", err)
+		}
+		switch tok {
+		case "data":
+			w.data = &jsonRowReader{dec: w.dec}
+		case "columns":
+			var cols []any
+			if err := w.dec.Decode(&cols); err != nil {
+				return nil, fmt.Errorf("wrapped_json: %w", err)
+			}
+			return Row(cols), nil
+		default:
+			var skip json.RawMessage
+			if err := w.dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("wrapped_json: %w", err)
+			}
+		}
+	}
+}
+
+// ---------- python ----------
+
+type pyRowReader struct {
+	p       *pyParser
+	started bool
+	done    bool
+}
+
+func (p *pyRowReader) Next() (Row, error) {
+	if p.done {
+		return nil, io.EOF
+	}
+	if !p.started {
+		c, err := p.p.peek()
+		if err == io.EOF {
+			p.done = true
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c != '[' {
+			return nil, fmt.Errorf("python: expected '[' at start of response, got %q", c)
+		}
+		p.p.r.ReadByte()
+		p.started = true
+	}
+	c, err := p.p.peek()
+	if err != nil {
+		return nil, p.p.unexpected(err)
+	}
+	if c == ',' {
+		p.p.r.ReadByte()
+		if c, err = p.p.peek(); err != nil {
+			return nil, p.p.unexpected(err)
+		}
+	}
+	if c == ']' {
+		p.p.r.ReadByte()
+		p.done = true
+		return nil, io.EOF
+	}
+	v, err := p.p.value()
+	if err != nil {
+		return nil, err
+	}
+	row, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("python: expected a list per row, got %T", v)
+	}
+	return Row(row), nil
+}
+
+// pyParser parses the subset of Python literals Livestatus emits:
+// lists, tuples, dicts, (u/b prefixed) strings, numbers, True/False/None.
+// Numbers are returned as json.Number so they behave like the json format.
+type pyParser struct {
+	r *bufio.Reader
+}
+
+var errPyUnexpectedEOF = errors.New("python: unexpected end of input")
+
+func (p *pyParser) unexpected(err error) error {
+	if err == io.EOF {
+		return errPyUnexpectedEOF
+	}
+	return err
+}
+
+// peek skips whitespace and returns the next byte without consuming it.
+func (p *pyParser) peek() (byte, error) {
+	for {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, p.r.UnreadByte()
+	}
+}
+
+func (p *pyParser) value() (any, error) {
+	c, err := p.peek()
+	if err != nil {
+		return nil, p.unexpected(err)
+	}
+	switch {
+	case c == '[':
+		p.r.ReadByte()
+		return p.sequence(']')
+	case c == '(':
+		p.r.ReadByte()
+		return p.sequence(')')
+	case c == '{':
+		p.r.ReadByte()
+		return p.dict()
+	case c == '\'' || c == '"':
+		p.r.ReadByte()
+		return p.str(c)
+	case c == 'u' || c == 'b':
+		p.r.ReadByte()
+		q, err := p.r.ReadByte()
+		if err != nil {
+			return nil, p.unexpected(err)
+		}
+		if q != '\'' && q != '"' {
+			return nil, fmt.Errorf("python: unexpected %q after string prefix %q", q, c)
+		}
+		return p.str(q)
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	default:
+		word, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		switch word {
+		case "True":
+			return true, nil
+		case "False":
+			return false, nil
+		case "None":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("python: unexpected token %q", word)
+	}
+}
+
+func (p *pyParser) sequence(end byte) ([]any, error) {
+	out := []any{}
+	for {
+		c, err := p.peek()
+		if err != nil {
+			return nil, p.unexpected(err)
+		}
+		if c == end {
+			p.r.ReadByte()
+			return out, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		c, err = p.peek()
+		if err != nil {
+			return nil, p.unexpected(err)
+		}
+		switch c {
+		case ',':
+			p.r.ReadByte()
+		case end:
+		default:
+			return nil, fmt.Errorf("python: expected ',' or %q, got %q", end, c)
+		}
+	}
+}
+
+func (p *pyParser) dict() (map[string]any, error) {
+	out := map[string]any{}
+	for {
+		c, err := p.peek()
+		if err != nil {
+			return nil, p.unexpected(err)
+		}
+		if c == '}' {
+			p.r.ReadByte()
+			return out, nil
+		}
+		k, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if c, err = p.peek(); err != nil {
+			return nil, p.unexpected(err)
+		}
+		if c != ':' {
+			return nil, fmt.Errorf("python: expected ':' in dict, got %q", c)
+		}
+		p.r.ReadByte()
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out[cellString(k)] = v
+		if c, err = p.peek(); err != nil {
+			return nil, p.unexpected(err)
+		}
+		switch c {
+		case ',':
+			p.r.ReadByte()
+		case '}':
+		default:
+			return nil, fmt.Errorf("python: expected ',' or '}', got %q", c)
+		}
+	}
+}
+
+func (p *pyParser) str(quote byte) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return "", p.unexpected(err)
+		}
+		if b == quote {
+			return sb.String(), nil
+		}
+		if b != '\\' {
+			sb.WriteByte(b)
+			continue
+		}
+		e, err := p.r.ReadByte()
+		if err != nil {
+			return "", p.unexpected(err)
+		}
+		switch e {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		case 'x', 'u', 'U':
+			n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+			hex := make([]byte, n)
+			if _, err := io.ReadFull(p.r, hex); err != nil {
+				return "", p.unexpected(err)
+			}
+			cp, err := strconv.ParseUint(string(hex), 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("python: invalid escape \\%c%s", e, hex)
+			}
+			if e == 'x' {
+				sb.WriteByte(byte(cp))
+			} else {
+				sb.WriteRune(rune(cp))
+			}
+		default:
+			// \\, \', \" and anything unknown map to the escaped byte itself
+			sb.WriteByte(e)
+		}
+	}
+}
+
+func (p *pyParser) number() (json.Number, error) {
+	var sb strings.Builder
+	for {
+		b, err := p.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if (b >= '0' && b <= '9') || b == '-' || b == '+' || b == '.' || b == 'e' || b == 'E' {
+			sb.WriteByte(b)
+			continue
+		}
+		if b != 'L' { // python2 long suffix
+			p.r.UnreadByte()
+		}
+		break
+	}
+	s := sb.String()
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return "", fmt.Errorf("python: invalid number %q", s)
+	}
+	return json.Number(s), nil
+}
+
+func (p *pyParser) word() (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := p.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if b < utf8.RuneSelf && (b == '_' || (b|0x20 >= 'a' && b|0x20 <= 'z')) {
+			sb.WriteByte(b)
+			continue
+		}
+		p.r.UnreadByte()
+		break
+	}
+	if sb.Len() == 0 {
+		b, _ := p.r.ReadByte()
+		return "", fmt.Errorf("python: unexpected character %q", b)
+	}
+	return sb.String(), nil
+}
+
+// cellString renders a decoded cell as a plain string (used for group keys and dict keys).
+func cellString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		if t {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// cellFloat converts a decoded cell into a float64.
+func cellFloat(v any) (float64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(t), 64)
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to number", v)
+	}
+}
@@ -0,0 +1,81 @@
+package livestatus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseRowsCSV(t *testing.T) {
+	is := is.New(t)
+
+	rows, err := parseRows(OutputCSV, []byte("a;1;x,y\n\nb;2;\n"), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(len(rows), 3)
+	is.Equal(rows[0], Row{"a", "1", "x,y"})
+	is.Equal(rows[1], Row{""}) // a single empty field
+	is.Equal(rows[2], Row{"b", "2", ""})
+
+	rows, err = parseRows(OutputCSV, nil, defaultSeparators)
+	is.NoErr(err)
+	is.Equal(len(rows), 0)
+}
+
+func TestParseRowsJSON(t *testing.T) {
+	is := is.New(t)
+
+	rows, err := parseRows(OutputJSON, []byte(`[["a",1,["x","y"],{"k":"v"}]]`), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+	is.Equal(rows[0][0], "a")
+	is.Equal(rows[0][1], json.Number("1"))
+	is.Equal(rows[0][2], []any{"x", "y"})
+	is.Equal(rows[0][3], map[string]any{"k": "v"})
+
+	_, err = parseRows(OutputJSON, []byte(`{"a":1}`), defaultSeparators)
+	is.True(err != nil)
+}
+
+func TestParseRowsPython(t *testing.T) {
+	is := is.New(t)
+
+	body := `[[u'it\'s',-1.5e3,[u"x",u"y"],{u'k':u'v'},True,None,12L,(1,2)],
+[b"\x41é",0,[],{},False,None,0,()]]
+`
+	rows, err := parseRows(OutputPY, []byte(body), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0], Row{"it's", json.Number("-1.5e3"), []any{"x", "y"}, map[string]any{"k": "v"}, true, nil, json.Number("12"), []any{json.Number("1"), json.Number("2")}})
+	is.Equal(rows[1][0], "Aé")
+	is.Equal(rows[1][2], []any{})
+
+	_, err = parseRows(OutputPY, []byte(`[[u'unterminated`), defaultSeparators)
+	is.True(err != nil)
+	_, err = parseRows(OutputPY, []byte(`[[nope]]`), defaultSeparators)
+	is.True(err != nil)
+}
+
+func TestParseRowsWrappedJSON(t *testing.T) {
+	is := is.New(t)
+
+	body := `{"columns":["name","state"],"data":[["a",0],["b",1]],"total_count":2}`
+	rows, err := parseRows(OutputWrappedJSON, []byte(body), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"name", "state"}, {"a", json.Number("0")}, {"b", json.Number("1")}})
+
+	rows, err = parseRows(OutputWrappedJSON, []byte(`{"total_count":0,"data":[]}`), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(len(rows), 0)
+
+	_, err = parseRows(OutputWrappedJSON, []byte(`[["a"]]`), defaultSeparators)
+	is.True(err != nil)
+}
+
+func TestParseRowsPython3(t *testing.T) {
+	is := is.New(t)
+
+	rows, err := parseRows(OutputPython3, []byte(`[['a',1,['x']]]`), defaultSeparators)
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"a", json.Number("1"), []any{"x"}}})
+}
@@ -0,0 +1,318 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ColumnType is the type reported by the Livestatus "columns" table.
+type ColumnType string
+
+const (
+	ColumnInt    ColumnType = "int"
+	ColumnFloat  ColumnType = "float"
+	ColumnString ColumnType = "string"
+	ColumnList   ColumnType = "list"
+	ColumnTime   ColumnType = "time"
+	ColumnDict   ColumnType = "dict"
+	ColumnBlob   ColumnType = "blob"
+)
+
+// ColumnInfo is one row of "GET columns".
+type ColumnInfo struct {
+	Table       Table      `livestatus:"table"`
+	Name        string     `livestatus:"name"`
+	Type        ColumnType `livestatus:"type"`
+	Description string     `livestatus:"description"`
+}
+
+// ValidationError lists everything wrong with a query.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid query: " + strings.Join(e.Problems, "; ")
+}
+
+// ErrInvalidQuery matches any *ValidationError via errors.Is.
+var ErrInvalidQuery = errors.New("invalid query")
+
+func (e *ValidationError) Is(target error) bool { return target == ErrInvalidQuery }
+
+// Schema is a cached copy of a site's column catalog. It is safe for concurrent
+// use and can be shared by several actors talking to sites of the same version.
+type Schema struct {
+	mu       sync.RWMutex
+	tables   map[Table]map[string]ColumnInfo
+	loadedAt time.Time
+}
+
+// SchemaQuery returns the query used to fetch the column catalog.
+// Its JSON response is also the format accepted by ParseSchema.
+func SchemaQuery() *LiveStatusQuery {
+	return NewLiveStatusQuery(Table("columns"), "table", "name", "type", "description").
+		OutputFormat(OutputJSON)
+}
+
+// NewSchema builds a schema from a list of columns.
+func NewSchema(cols []ColumnInfo) *Schema {
+	s := &Schema{}
+	s.set(cols)
+	return s
+}
+
+// LoadSchema fetches the column catalog from a site.
+func LoadSchema(ctx context.Context, cfg *LiveStatusConfig) (*Schema, error) {
+	s := &Schema{}
+	if err := s.Refresh(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseSchema builds a schema from a saved response to SchemaQuery, which allows
+// validation and code generation without access to a site. A leading header
+// row (as sent with ColumnHeaders: on) is detected and used for column order.
+func ParseSchema(data []byte) (*Schema, error) {
+	cols, err := decodeColumns(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchema(cols), nil
+}
+
+// Refresh reloads the catalog from a site, replacing the cached copy.
+func (s *Schema) Refresh(ctx context.Context, cfg *LiveStatusConfig) error {
+	res, err := QueryOneOffFromBuilder(ctx, SchemaQuery(), cfg)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return fmt.Errorf("loading schema: %w", res.Error)
+	}
+	cols, err := decodeColumns(res.Data)
+	if err != nil {
+		return fmt.Errorf("loading schema: %w", err)
+	}
+	s.set(cols)
+	return nil
+}
+
+func decodeColumns(data []byte) ([]ColumnInfo, error) {
+	q := SchemaQuery()
+	rows, err := parseRows(OutputJSON, data, defaultSeparators)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && slices.Contains(rows[0], any("table")) && slices.Contains(rows[0], any("type")) {
+		q.ColumnHeaders(true)
+	}
+	var cols []ColumnInfo
+	if err := Unmarshal(q, data, &cols); err != nil {
+		return nil, err
+	}
+	return cols, nil
+}
+
+func (s *Schema) set(cols []ColumnInfo) {
+	tables := make(map[Table]map[string]ColumnInfo)
+	for _, c := range cols {
+		if tables[c.Table] == nil {
+			tables[c.Table] = make(map[string]ColumnInfo)
+		}
+		tables[c.Table][c.Name] = c
+	}
+	s.mu.Lock()
+	s.tables = tables
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// LoadedAt returns when the catalog was last (re)loaded.
+func (s *Schema) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadedAt
+}
+
+// Tables returns the known table names, sorted.
+func (s *Schema) Tables() []Table {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Table, 0, len(s.tables))
+	for t := range s.tables {
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Columns returns the columns of a table sorted by name, or nil if it is unknown.
+func (s *Schema) Columns(t Table) []ColumnInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cols := s.tables[t]
+	if cols == nil {
+		return nil
+	}
+	out := make([]ColumnInfo, 0, len(cols))
+	for _, c := range cols {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Column looks up a single column.
+func (s *Schema) Column(t Table, name string) (ColumnInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.tables[t][name]
+	return c, ok
+}
+
+// Validate checks a query against the catalog: unknown tables and columns,
+// operators that don't fit the column type, and malformed Stats, in addition to
+// the structural checks of LiveStatusQuery.Validate. It returns a
+// *ValidationError listing every problem found, or nil.
+func (s *Schema) Validate(q *LiveStatusQuery) error {
+	if q == nil {
+		return &ValidationError{Problems: []string{"query is nil"}}
+	}
+	s.mu.RLock()
+	cols, ok := s.tables[q.table]
+	s.mu.RUnlock()
+	if !ok {
+		return &ValidationError{Problems: []string{fmt.Sprintf("unknown table %q", q.table)}}
+	}
+
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	lookup := func(name string) (ColumnInfo, bool) {
+		c, ok := cols[name]
+		if !ok {
+			addf("unknown column %q in table %q", name, q.table)
+		}
+		return c, ok
+	}
+
+	for _, name := range q.columns {
+		lookup(name)
+	}
+	checkCond := func(kind, value string) {
+		column, op, operand, err := splitCondition(value)
+		if err != nil {
+			addf("%s %q: %v", kind, value, err)
+			return
+		}
+		if c, ok := lookup(column); ok {
+			if err := checkOperator(c, op, operand); err != nil {
+				addf("%s %q: %v", kind, value, err)
+			}
+		}
+	}
+
+	for _, line := range q.filters {
+		if key, value := headerKV(line); key == "Filter" {
+			checkCond("Filter", value)
+		}
+	}
+	for _, line := range q.headers {
+		if key, value := headerKV(line); key == "WaitCondition" {
+			checkCond("WaitCondition", value)
+		}
+	}
+	for _, line := range q.stats {
+		key, value := headerKV(line)
+		if key != "Stats" {
+			continue
+		}
+		fn, column, _ := strings.Cut(value, " ")
+		if slices.Contains(knownStatsFuncs, StatsFunc(fn)) {
+			if c, ok := lookup(strings.TrimSpace(column)); ok {
+				switch c.Type {
+				case ColumnInt, ColumnFloat, ColumnTime:
+				default:
+					addf("Stats %q: %s needs a numeric column, %q is %s", value, fn, c.Name, c.Type)
+				}
+			}
+			continue
+		}
+		checkCond("Stats", value)
+	}
+	problems = append(problems, q.problems()...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkOperator reports whether op (with operand) is meaningful for the column type.
+func checkOperator(c ColumnInfo, op Op, operand string) error {
+	switch c.Type {
+	case ColumnInt, ColumnTime:
+		if !slices.Contains([]Op{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}, op) {
+			return fmt.Errorf("operator %s not supported on %s column %q", op, c.Type, c.Name)
+		}
+		if _, err := strconv.ParseInt(strings.TrimSpace(operand), 10, 64); err != nil {
+			return fmt.Errorf("%q is not an integer", operand)
+		}
+	case ColumnFloat:
+		if !slices.Contains([]Op{OpEq, OpNe, OpLt, OpLe, OpGt, OpGe}, op) {
+			return fmt.Errorf("operator %s not supported on float column %q", op, c.Name)
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSpace(operand), 64); err != nil {
+			return fmt.Errorf("%q is not a number", operand)
+		}
+	case ColumnList:
+		switch op {
+		case OpEq, OpNe:
+			// On lists = and != only test for emptiness.
+			if operand != "" {
+				return fmt.Errorf("list column %q only supports %s with an empty value; use >= (contains) or < (does not contain)", c.Name, op)
+			}
+		case OpEqIC, OpNeIC:
+			return fmt.Errorf("operator %s not supported on list column %q", op, c.Name)
+		}
+	case ColumnDict:
+		if !slices.Contains([]Op{OpEq, OpNe, OpEqIC, OpNeIC, OpRe, OpReIC, OpNRe, OpNReIC}, op) {
+			return fmt.Errorf("operator %s not supported on dict column %q", op, c.Name)
+		}
+		if key, _, _ := strings.Cut(operand, " "); key == "" {
+			return fmt.Errorf("dict column %q needs \"<key> <value>\"", c.Name)
+		}
+	case ColumnBlob:
+		return fmt.Errorf("blob column %q cannot be filtered", c.Name)
+	}
+	return nil
+}
+
+// headerKV splits "Key: value" into its parts.
+func headerKV(line string) (string, string) {
+	key, value, _ := strings.Cut(line, ":")
+	return key, strings.TrimLeft(value, " ")
+}
+
+// splitCondition splits "column op value"; the value may be empty or contain spaces.
+func splitCondition(s string) (string, Op, string, error) {
+	column, rest, ok := strings.Cut(s, " ")
+	if column == "" || !ok {
+		return "", "", "", fmt.Errorf("expected \"<column> <operator> <value>\"")
+	}
+	opStr, value, _ := strings.Cut(strings.TrimLeft(rest, " "), " ")
+	if !slices.Contains(knownOps, Op(opStr)) {
+		return "", "", "", fmt.Errorf("unknown operator %q", opStr)
+	}
+	return column, Op(opStr), value, nil
+}
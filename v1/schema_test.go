@@ -0,0 +1,132 @@
+package livestatus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const testColumnsDump = `[
+["hosts","name","string","Host name"],
+["hosts","state","int","Current state"],
+["hosts","latency","float","Check latency"],
+["hosts","last_check","time","Time of the last check"],
+["hosts","parents","list","Parent hosts"],
+["hosts","custom_variables","dict","Custom variables"],
+["services","host_name","string","Host name"],
+["services","state","int","Current state"]
+]`
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	s, err := ParseSchema([]byte(testColumnsDump))
+	if err != nil {
+		t.Fatalf("ParseSchema: %v", err)
+	}
+	return s
+}
+
+func TestParseSchema(t *testing.T) {
+	is := is.New(t)
+	s := testSchema(t)
+
+	is.Equal(s.Tables(), []Table{"hosts", "services"})
+	c, ok := s.Column("hosts", "parents")
+	is.True(ok)
+	is.Equal(c.Type, ColumnList)
+	is.Equal(len(s.Columns("hosts")), 6)
+	is.Equal(s.Columns("nope"), nil)
+
+	// A dump taken with ColumnHeaders: on is also accepted, in any column order.
+	s, err := ParseSchema([]byte(`[["name","table","type","description"],["name","hosts","string","Host name"]]`))
+	is.NoErr(err)
+	c, ok = s.Column("hosts", "name")
+	is.True(ok)
+	is.Equal(c.Description, "Host name")
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := testSchema(t)
+
+	ok := []*LiveStatusQuery{
+		NewLiveStatusQuery("hosts", "name", "state").FilterEqual("state", "1"),
+		NewLiveStatusQuery("hosts").Filter("parents", OpGe, "gw1").Filter("parents", OpEq, ""),
+		NewLiveStatusQuery("hosts").Filter("custom_variables", OpEq, "ROLE web"),
+		NewLiveStatusQuery("hosts", "state").Stats("state", OpEq, "0").StatsAvg("latency").StatsMax("last_check"),
+		NewLiveStatusQuery("services").FilterExpr(Or(Eq("state", "1"), ReI("host_name", "^web"))),
+	}
+	for i, q := range ok {
+		if err := s.Validate(q); err != nil {
+			t.Errorf("query %d: unexpected error: %v", i, err)
+		}
+	}
+
+	bad := map[string]*LiveStatusQuery{
+		"unknown table":     NewLiveStatusQuery("nope"),
+		"unknown column":    NewLiveStatusQuery("hosts", "nmae"),
+		"unknown filter":    NewLiveStatusQuery("hosts").FilterEqual("sate", "1"),
+		"regex on int":      NewLiveStatusQuery("hosts").FilterRegex("state", "1"),
+		"non-numeric value": NewLiveStatusQuery("hosts").FilterEqual("state", "two"),
+		"list equality":     NewLiveStatusQuery("hosts").FilterEqual("parents", "gw1"),
+		"dict without key":  NewLiveStatusQuery("hosts").FilterEqual("custom_variables", ""),
+		"sum of string":     NewLiveStatusQuery("hosts").StatsSum("name"),
+		"stats unknown col": NewLiveStatusQuery("hosts").Stats("nope", OpEq, "1"),
+		"And overflow":      NewLiveStatusQuery("hosts").FilterEqual("state", "1").And(2),
+		"StatsOr overflow":  NewLiveStatusQuery("hosts").Stats("state", OpEq, "1").StatsOr(3),
+		"wait condition":    NewLiveStatusQuery("hosts").WaitCondition("state ~ x"),
+	}
+	for name, q := range bad {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			err := s.Validate(q)
+			is.True(errors.Is(err, ErrInvalidQuery))
+			var ve *ValidationError
+			is.True(errors.As(err, &ve))
+			is.True(len(ve.Problems) > 0)
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		return StatusOK, testColumnsDump
+	})
+	cfg := NewLiveStatusConfig(addr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := LoadSchema(ctx, cfg)
+	is.NoErr(err)
+	_, ok := s.Column("services", "host_name")
+	is.True(ok)
+	is.True(!s.LoadedAt().IsZero())
+}
+
+func TestActorRejectsInvalidQueries(t *testing.T) {
+	is := is.New(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	results := make(chan ResultMsg, 2)
+	actor := NewLiveStatusActor(logger, "test_schema", nil, 2, results, prometheus.NewRegistry())
+	defer actor.Close()
+	actor.SetSchema(testSchema(t))
+	is.NoErr(actor.Start(context.Background()))
+
+	_, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery("hosts", "nmae"))
+	is.True(errors.Is(err, ErrInvalidQuery))
+	is.True(strings.Contains(err.Error(), `unknown column "nmae"`))
+
+	_, ok := actor.TrySendQuery(*NewLiveStatusQuery("hosts").FilterRegex("state", "x"))
+	is.True(!ok)
+
+	id, err := actor.SendQuery(context.Background(), *NewLiveStatusQuery("hosts", "name"))
+	is.NoErr(err)
+	is.Equal(recvResult(t, results, time.Second).ID, id)
+}
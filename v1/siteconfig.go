@@ -0,0 +1,208 @@
+package livestatus
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Duration is a time.Duration written like "5s" or "1m30s" in config files.
+type Duration time.Duration
+
+// UnmarshalText parses a duration string (see time.ParseDuration).
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration like time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// SitesFile is the content of a multi-site configuration file:
+//
+//	defaults:
+//	  connect_timeout: 5s
+//	  queue_capacity: 200
+//	sites:
+//	  - name: eu1
+//	    address: tls://eu1.example.com:6557
+//	    labels: {region: eu}
+//	    tls: {ca_file: /etc/livestatus/ca.pem}
+//	  - name: local
+//	    address: unix:///omd/sites/local/tmp/run/live
+//
+// Fields left empty in a site are taken from defaults; labels are merged.
+type SitesFile struct {
+	Defaults SiteConfig   `json:"defaults" yaml:"defaults"`
+	Sites    []SiteConfig `json:"sites" yaml:"sites"`
+}
+
+// SiteConfig describes one site of a SitesFile.
+type SiteConfig struct {
+	Name string `json:"name" yaml:"name"`
+	// Address is a host:port, socket path or address URI (see SetAddress).
+	Address string            `json:"address" yaml:"address"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	TLS     *SiteTLS          `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// AuthUser is sent as AuthUser with every MultiSite query on the site, so
+	// only objects this contact may see are returned.
+	AuthUser string `json:"auth_user,omitempty" yaml:"auth_user,omitempty"`
+
+	ConnectTimeout Duration `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`
+	ReadTimeout    Duration `json:"read_timeout,omitempty" yaml:"read_timeout,omitempty"`
+	WriteTimeout   Duration `json:"write_timeout,omitempty" yaml:"write_timeout,omitempty"`
+	// QueryTimeout bounds each MultiSite query on the site (see Site.Timeout).
+	QueryTimeout Duration `json:"query_timeout,omitempty" yaml:"query_timeout,omitempty"`
+
+	QueueCapacity int `json:"queue_capacity,omitempty" yaml:"queue_capacity,omitempty"`
+	PoolSize      int `json:"pool_size,omitempty" yaml:"pool_size,omitempty"`
+}
+
+// SiteTLS enables TLS for a site; the files hold PEM data.
+type SiteTLS struct {
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// LoadSitesFile reads a multi-site configuration file. Files ending in .json
+// are read as JSON, anything else as YAML. Unknown fields are rejected, and
+// the sites are validated and completed with the defaults.
+func LoadSitesFile(path string) (*SitesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseSitesFile(data, filepath.Ext(path) == ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// ParseSitesFile parses a multi-site configuration like LoadSitesFile.
+func ParseSitesFile(data []byte, isJSON bool) (*SitesFile, error) {
+	var f SitesFile
+	if isJSON {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+	}
+	seen := make(map[string]bool, len(f.Sites))
+	for i := range f.Sites {
+		s := f.Defaults.merge(f.Sites[i])
+		if s.Name == "" {
+			return nil, fmt.Errorf("site %d: name is missing", i)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("site %q is defined twice", s.Name)
+		}
+		seen[s.Name] = true
+		if _, err := s.LiveStatusConfig(); err != nil {
+			return nil, fmt.Errorf("site %q: %w", s.Name, err)
+		}
+		f.Sites[i] = s
+	}
+	return &f, nil
+}
+
+// merge fills the fields s leaves empty from d.
+func (d SiteConfig) merge(s SiteConfig) SiteConfig {
+	if len(d.Labels) > 0 {
+		labels := maps.Clone(d.Labels)
+		maps.Copy(labels, s.Labels)
+		s.Labels = labels
+	}
+	if s.TLS == nil && d.TLS != nil {
+		tls := *d.TLS
+		s.TLS = &tls
+	}
+	if s.AuthUser == "" {
+		s.AuthUser = d.AuthUser
+	}
+	if s.ConnectTimeout == 0 {
+		s.ConnectTimeout = d.ConnectTimeout
+	}
+	if s.ReadTimeout == 0 {
+		s.ReadTimeout = d.ReadTimeout
+	}
+	if s.WriteTimeout == 0 {
+		s.WriteTimeout = d.WriteTimeout
+	}
+	if s.QueryTimeout == 0 {
+		s.QueryTimeout = d.QueryTimeout
+	}
+	if s.QueueCapacity == 0 {
+		s.QueueCapacity = d.QueueCapacity
+	}
+	if s.PoolSize == 0 {
+		s.PoolSize = d.PoolSize
+	}
+	return s
+}
+
+// LiveStatusConfig returns the connection configuration of the site, with the
+// defaults of NewLiveStatusConfig for anything not set.
+func (s SiteConfig) LiveStatusConfig() (*LiveStatusConfig, error) {
+	if s.Address == "" {
+		return nil, fmt.Errorf("address is missing")
+	}
+	if s.ConnectTimeout < 0 || s.ReadTimeout < 0 || s.WriteTimeout < 0 || s.QueryTimeout < 0 {
+		return nil, fmt.Errorf("timeouts cannot be negative")
+	}
+	if s.QueueCapacity < 0 || s.PoolSize < 0 {
+		return nil, fmt.Errorf("queue_capacity and pool_size cannot be negative")
+	}
+	cfg, err := NewLiveStatusConfigURI(s.Address)
+	if err != nil {
+		return nil, err
+	}
+	if s.TLS != nil {
+		if cfg.Network == "unix" {
+			return nil, fmt.Errorf("tls needs a TCP address")
+		}
+		// Settings from a tls:// URI stay unless the block overrides them.
+		cfg.UseTLS = true
+		cfg.CAFile = cmp.Or(s.TLS.CAFile, cfg.CAFile)
+		cfg.CertFile = cmp.Or(s.TLS.CertFile, cfg.CertFile)
+		cfg.KeyFile = cmp.Or(s.TLS.KeyFile, cfg.KeyFile)
+		cfg.ServerName = cmp.Or(s.TLS.ServerName, cfg.ServerName)
+		cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || s.TLS.InsecureSkipVerify
+		if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+			return nil, fmt.Errorf("tls needs both cert_file and key_file for a client certificate")
+		}
+	}
+	if s.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = time.Duration(s.ConnectTimeout)
+	}
+	if s.ReadTimeout > 0 {
+		cfg.ReadTimeout = time.Duration(s.ReadTimeout)
+	}
+	if s.WriteTimeout > 0 {
+		cfg.WriteTimeout = time.Duration(s.WriteTimeout)
+	}
+	return cfg, nil
+}
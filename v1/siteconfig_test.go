@@ -0,0 +1,101 @@
+package livestatus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestParseSitesFileYAML(t *testing.T) {
+	is := is.New(t)
+	f, err := ParseSitesFile([]byte(`
+defaults:
+  connect_timeout: 2s
+  queue_capacity: 50
+  labels: {env: prod}
+sites:
+  - name: eu1
+    address: tls://eu1.example.com?servername=eu1.internal
+    labels: {region: eu}
+    tls: {ca_file: /etc/ca.pem}
+    auth_user: dashboards
+    query_timeout: 1m30s
+  - name: local
+    address: /omd/sites/local/tmp/run/live
+    queue_capacity: 10
+    labels: {env: test}
+`), false)
+	is.NoErr(err)
+	is.Equal(len(f.Sites), 2)
+
+	eu := f.Sites[0]
+	is.Equal(eu.Labels, map[string]string{"env": "prod", "region": "eu"})
+	is.Equal(eu.QueueCapacity, 50)
+	is.Equal(time.Duration(eu.QueryTimeout), 90*time.Second)
+	cfg, err := eu.LiveStatusConfig()
+	is.NoErr(err)
+	is.Equal(cfg.Address, "eu1.example.com:6557")
+	is.True(cfg.UseTLS)
+	is.Equal(cfg.CAFile, "/etc/ca.pem")
+	is.Equal(cfg.ServerName, "eu1.internal") // kept from the URI
+	is.Equal(cfg.ConnectTimeout, 2*time.Second)
+	is.Equal(cfg.ReadTimeout, 30*time.Second) // NewLiveStatusConfig default
+
+	local := f.Sites[1]
+	is.Equal(local.Labels, map[string]string{"env": "test"})
+	is.Equal(local.QueueCapacity, 10)
+	cfg, err = local.LiveStatusConfig()
+	is.NoErr(err)
+	is.Equal(cfg.Network, "unix")
+}
+
+func TestParseSitesFileJSON(t *testing.T) {
+	is := is.New(t)
+	f, err := ParseSitesFile([]byte(`{"sites": [{"name": "a", "address": "tcp://mon:6557", "read_timeout": "5s", "pool_size": 2}]}`), true)
+	is.NoErr(err)
+	is.Equal(f.Sites[0].PoolSize, 2)
+	is.Equal(time.Duration(f.Sites[0].ReadTimeout), 5*time.Second)
+}
+
+func TestParseSitesFileErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data   string
+		isJSON bool
+		want   string
+	}{
+		"unknown field":  {"sites:\n  - name: a\n    adress: mon:6557\n", false, "adress"},
+		"unknown json":   {`{"sites": [{"name": "a", "adress": "mon:6557"}]}`, true, "adress"},
+		"no name":        {"sites:\n  - address: mon:6557\n", false, "name is missing"},
+		"no address":     {"sites:\n  - name: a\n", false, "address is missing"},
+		"duplicate":      {"sites:\n  - {name: a, address: x:1}\n  - {name: a, address: y:1}\n", false, "defined twice"},
+		"bad duration":   {"sites:\n  - {name: a, address: x:1, read_timeout: soon}\n", false, "soon"},
+		"tls on socket":  {"sites:\n  - {name: a, address: /run/live, tls: {}}\n", false, "TCP"},
+		"half a keypair": {"sites:\n  - {name: a, address: x:1, tls: {cert_file: c.pem}}\n", false, "key_file"},
+		"bad uri":        {"sites:\n  - {name: a, address: 'ftp://x'}\n", false, "ftp"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSitesFile([]byte(tc.data), tc.isJSON)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadSitesFileByExtension(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sites.json")
+	is.NoErr(os.WriteFile(path, []byte(`{"sites": [{"name": "a", "address": "mon:6557"}]}`), 0o600))
+
+	f, err := LoadSitesFile(path)
+	is.NoErr(err)
+	is.Equal(f.Sites[0].Name, "a")
+
+	_, err = LoadSitesFile(filepath.Join(dir, "missing.yaml"))
+	is.True(err != nil)
+}
@@ -0,0 +1,276 @@
+package livestatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SiteManager builds and runs one LiveStatusActor per site of a configuration
+// file (see SitesFile) and keeps them in sync with the file: it polls the file
+// and adds, removes or reconfigures sites when it changes. Sites whose entry
+// didn't change are left alone, and new connection settings are applied to the
+// running actor (see UpdateConfig), so queued requests survive; only a changed
+// queue_capacity or pool_size replaces the actor.
+type SiteManager struct {
+	logger   *slog.Logger
+	path     string
+	results  chan<- ResultMsg
+	reg      prometheus.Registerer
+	events   chan<- ConnectivityEvent
+	interval time.Duration
+
+	multi *MultiSite
+
+	mu    sync.Mutex // serializes reloads
+	sites map[string]*managedSite
+	data  []byte // content of the file last applied
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// managedSite is a site together with the actor built for it.
+type managedSite struct {
+	cfg   SiteConfig
+	actor *LiveStatusActor
+}
+
+// NewSiteManager returns a manager for the configuration file at path. All
+// actors publish to results (see NewLiveStatusActor) and register their
+// metrics with reg, labelled by site.
+func NewSiteManager(logger *slog.Logger, path string, results chan<- ResultMsg, reg prometheus.Registerer) *SiteManager {
+	multi, _ := NewMultiSite()
+	return &SiteManager{
+		logger:   logger.With("scope", "SiteManager", "path", path),
+		path:     path,
+		results:  results,
+		reg:      reg,
+		interval: 5 * time.Second,
+		multi:    multi,
+		sites:    make(map[string]*managedSite),
+	}
+}
+
+// SetEventChan sets the connectivity event channel of all actors. Call it before Start.
+func (m *SiteManager) SetEventChan(ch chan<- ConnectivityEvent) {
+	m.events = ch
+}
+
+// SetPollInterval sets how often the file is checked for changes (default 5s).
+// Call it before Start.
+func (m *SiteManager) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		m.interval = d
+	}
+}
+
+// Start loads the file, starts an actor per site and begins watching the file.
+// The actors run until ctx is done or Close is called. If the file can't be
+// loaded or a site can't be started, Start returns the error, leaves no actor
+// running and may be called again.
+func (m *SiteManager) Start(ctx context.Context) error {
+	if ctx == nil {
+		return fmt.Errorf("ctx cannot be nil")
+	}
+	if m.ctx != nil {
+		return fmt.Errorf("site manager already started")
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	if err := m.Reload(); err != nil {
+		// Leave the manager unstarted, so Start can be called again.
+		m.cancel()
+		m.ctx, m.cancel = nil, nil
+		m.closeSites()
+		return err
+	}
+	m.wg.Add(1)
+	go m.watch()
+	return nil
+}
+
+// Sites returns the MultiSite of the managed sites. It stays current across reloads.
+func (m *SiteManager) Sites() *MultiSite {
+	return m.multi
+}
+
+// Actor returns the actor of the named site.
+func (m *SiteManager) Actor(name string) (*LiveStatusActor, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sites[name]
+	if !ok {
+		return nil, false
+	}
+	return s.actor, true
+}
+
+// Reload applies the file now if it changed since it was last applied. An
+// invalid file is reported and leaves the running sites untouched. Sites whose
+// settings can't be applied, say because a CA file can't be read yet, are
+// reported together (see errors.Join) while the other sites are updated: a new
+// site is not started, and a changed one keeps its old settings. Both are
+// retried on the next poll even if the file hasn't changed.
+func (m *SiteManager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil {
+		return fmt.Errorf("site manager not started")
+	}
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return err
+	}
+	if m.data != nil && bytes.Equal(data, m.data) {
+		return nil
+	}
+	f, err := ParseSitesFile(data, filepath.Ext(m.path) == ".json")
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.path, err)
+	}
+	if err := m.apply(f); err != nil {
+		return err
+	}
+	m.data = data
+	return nil
+}
+
+// apply brings the running sites in line with f, which has been validated. It
+// returns the errors of the sites that could not be started or reconfigured.
+func (m *SiteManager) apply(f *SitesFile) error {
+	var errs []error
+	keep := make(map[string]bool, len(f.Sites))
+	for _, cfg := range f.Sites {
+		keep[cfg.Name] = true
+		cur, ok := m.sites[cfg.Name]
+		switch {
+		case !ok:
+			m.logger.Info("site added", "site", cfg.Name)
+			if err := m.startSite(cfg); err != nil {
+				errs = append(errs, err)
+			}
+		case reflect.DeepEqual(cur.cfg, cfg):
+			// unchanged
+		case reflect.DeepEqual(cur.cfg.actorSettings(), cfg.actorSettings()):
+			// Only MultiSite settings changed; the actor keeps running.
+			m.logger.Info("site updated", "site", cfg.Name)
+			cur.cfg = cfg
+			m.multi.put(cfg.site(cur.actor))
+		case cur.cfg.QueueCapacity == cfg.QueueCapacity && cur.cfg.PoolSize == cfg.PoolSize:
+			// The actor switches to the new connection settings with its queue.
+			m.logger.Info("site reconfigured", "site", cfg.Name)
+			lsc, _ := cfg.LiveStatusConfig() // validated by ParseSitesFile
+			if err := cur.actor.UpdateConfig(lsc); err != nil {
+				errs = append(errs, fmt.Errorf("site %q: %w", cfg.Name, err))
+				continue
+			}
+			cur.cfg = cfg
+			m.multi.put(cfg.site(cur.actor))
+		default:
+			// Queue and pool are sized at construction, so resizing takes a new actor.
+			m.logger.Info("site rebuilt", "site", cfg.Name)
+			old := cur.actor
+			if err := m.startSite(cfg); err != nil {
+				errs = append(errs, err) // the old actor keeps serving
+				continue
+			}
+			old.Close()
+		}
+	}
+	for name, cur := range m.sites {
+		if !keep[name] {
+			m.logger.Info("site removed", "site", name)
+			m.multi.Remove(name)
+			delete(m.sites, name)
+			cur.actor.Close()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startSite starts an actor for cfg and makes it the site's actor. Like
+// UpdateConfig, it refuses settings it can't dial with.
+func (m *SiteManager) startSite(cfg SiteConfig) error {
+	lsc, _ := cfg.LiveStatusConfig() // validated by ParseSitesFile
+	if _, err := lsc.dialer(); err != nil {
+		return fmt.Errorf("site %q: invalid config: %w", cfg.Name, err)
+	}
+	actor := NewLiveStatusActor(m.logger, cfg.Name, lsc, cfg.QueueCapacity, m.results, m.reg)
+	if cfg.PoolSize > 0 {
+		actor.SetPoolSize(cfg.PoolSize)
+	}
+	if m.events != nil {
+		actor.SetEventChan(m.events)
+	}
+	_ = actor.Start(m.ctx) // only fails for a started actor
+	m.sites[cfg.Name] = &managedSite{cfg: cfg, actor: actor}
+	m.multi.put(cfg.site(actor))
+	return nil
+}
+
+// actorSettings returns s without the settings that only MultiSite uses.
+func (s SiteConfig) actorSettings() SiteConfig {
+	s.Labels, s.AuthUser, s.QueryTimeout = nil, "", 0
+	return s
+}
+
+// site returns the MultiSite entry for s served by actor.
+func (s SiteConfig) site(actor *LiveStatusActor) Site {
+	return Site{
+		Name:     s.Name,
+		Labels:   s.Labels,
+		Actor:    actor,
+		Timeout:  time.Duration(s.QueryTimeout),
+		AuthUser: s.AuthUser,
+	}
+}
+
+// watch polls the file until the manager is closed.
+func (m *SiteManager) watch() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Reload(); err != nil {
+				m.logger.Warn("site config reload failed; keeping the running sites", "err", err)
+			}
+		}
+	}
+}
+
+// Close stops watching the file and closes all actors.
+func (m *SiteManager) Close() {
+	m.closeOnce.Do(func() {
+		if m.cancel != nil {
+			m.cancel()
+		}
+		m.wg.Wait()
+		m.closeSites()
+	})
+}
+
+// closeSites closes and forgets all actors.
+func (m *SiteManager) closeSites() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, s := range m.sites {
+		m.multi.Remove(name)
+		s.actor.Close()
+	}
+	m.sites = make(map[string]*managedSite)
+}
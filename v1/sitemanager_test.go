@@ -0,0 +1,219 @@
+package livestatus
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func writeSites(t *testing.T, path, content string) {
+	t.Helper()
+	// Write and rename, like config management tools do.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestSiteManager(t *testing.T, content string) (*SiteManager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sites.yaml")
+	writeSites(t, path, content)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	m := NewSiteManager(logger, path, nil, prometheus.NewRegistry())
+	m.SetPollInterval(10 * time.Millisecond)
+	t.Cleanup(m.Close)
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return m, path
+}
+
+func TestSiteManagerStartsSites(t *testing.T) {
+	is := is.New(t)
+	a, _ := startFakeLivestatus(t, func(req string) (int, string) {
+		if !strings.Contains(req, "AuthUser: dash\n") {
+			return StatusForbidden, "no AuthUser"
+		}
+		return StatusOK, `[["web01"]]`
+	})
+	b, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[["web02"]]` })
+	m, _ := newTestSiteManager(t, fmt.Sprintf(`
+sites:
+  - {name: a, address: "%s", auth_user: dash, labels: {region: eu}}
+  - {name: b, address: "tcp://%s", pool_size: 2}
+`, a, b))
+
+	is.Equal(m.Sites().Sites(), []string{"a", "b"})
+	actor, ok := m.Actor("b")
+	is.True(ok)
+	is.Equal(actor.PoolSize(), 2)
+
+	q := NewLiveStatusQuery(Table("hosts"), "name").OutputFormat(OutputJSON)
+	res, err := m.Sites().Query(context.Background(), *q, SiteSelector{})
+	is.NoErr(err)
+	is.Equal(len(res.Failed()), 0)
+	is.Equal(res.Rows, []Row{{"a", "web01"}, {"b", "web02"}})
+}
+
+func TestSiteManagerReload(t *testing.T) {
+	is := is.New(t)
+	addr, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[]` })
+	m, path := newTestSiteManager(t, fmt.Sprintf(`
+sites:
+  - {name: keep, address: "%[1]s"}
+  - {name: relabel, address: "%[1]s", labels: {tier: test}}
+  - {name: retune, address: "%[1]s"}
+  - {name: resize, address: "%[1]s"}
+  - {name: drop, address: "%[1]s"}
+`, addr))
+	keep, _ := m.Actor("keep")
+	relabel, _ := m.Actor("relabel")
+	retune, _ := m.Actor("retune")
+	resize, _ := m.Actor("resize")
+	drop, _ := m.Actor("drop")
+
+	writeSites(t, path, fmt.Sprintf(`
+sites:
+  - {name: keep, address: "%[1]s"}
+  - {name: relabel, address: "%[1]s", labels: {tier: prod}}
+  - {name: retune, address: "%[1]s", read_timeout: 5s}
+  - {name: resize, address: "%[1]s", pool_size: 2}
+  - {name: new, address: "%[1]s"}
+`, addr))
+	waitFor(t, "reload", func() bool { _, ok := m.Actor("new"); return ok })
+
+	is.Equal(m.Sites().Sites(), []string{"keep", "new", "relabel", "resize", "retune"})
+	is.True(drop.closed.Load())
+
+	got, _ := m.Actor("keep")
+	is.True(got == keep) // untouched
+	is.True(!keep.closed.Load())
+
+	got, _ = m.Actor("relabel")
+	is.True(got == relabel) // same actor, new labels
+	site, _ := m.Sites().Site("relabel")
+	is.Equal(site.Labels["tier"], "prod")
+
+	got, _ = m.Actor("retune")
+	is.True(got == retune) // same actor, new timeout
+	is.True(!retune.closed.Load())
+	is.Equal(got.Config().ReadTimeout, 5*time.Second)
+
+	got, _ = m.Actor("resize")
+	is.True(got != resize) // rebuilt with the new pool
+	is.True(resize.closed.Load())
+	is.Equal(got.PoolSize(), 2)
+}
+
+func TestSiteManagerKeepsSitesOnBadFile(t *testing.T) {
+	is := is.New(t)
+	m, path := newTestSiteManager(t, "sites:\n  - {name: a, address: 'mon:6557'}\n")
+	a, _ := m.Actor("a")
+
+	writeSites(t, path, "sites:\n  - {name: a}\n") // no address
+	is.True(m.Reload() != nil)
+	got, _ := m.Actor("a")
+	is.True(got == a)
+	is.True(!a.closed.Load())
+}
+
+func TestSiteManagerStartFails(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "sites.json")
+	writeSites(t, path, `{"sites": [{"name": "a"}]}`)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	m := NewSiteManager(logger, path, nil, prometheus.NewRegistry())
+	defer m.Close()
+
+	err := m.Start(context.Background())
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "address is missing"))
+	is.True(m.Reload() != nil) // still not started
+
+	// Once the file is fixed, Start works.
+	addr, _ := startFakeLivestatus(t, func(string) (int, string) { return StatusOK, `[]` })
+	writeSites(t, path, fmt.Sprintf(`{"sites": [{"name": "a", "address": %q}]}`, addr))
+	is.NoErr(m.Start(context.Background()))
+	actor, ok := m.Actor("a")
+	is.True(ok)
+	res, err := actor.Query(context.Background(), *NewLiveStatusQuery(Table("hosts")))
+	is.NoErr(err)
+	is.Equal(res.StatusCode, StatusOK)
+}
+
+func TestSiteManagerRetriesRejectedConfig(t *testing.T) {
+	is := is.New(t)
+	m, path := newTestSiteManager(t, "sites:\n  - {name: a, address: 'mon:6557'}\n")
+	a, _ := m.Actor("a")
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+
+	// a changes and b is added, both with a CA file that doesn't exist yet.
+	writeSites(t, path, fmt.Sprintf(`
+sites:
+  - {name: a, address: 'mon:6557', tls: {ca_file: %[1]q}}
+  - {name: b, address: 'mon:6557', tls: {ca_file: %[1]q}}
+`, ca))
+	err := m.Reload()
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `site "a"`))
+	is.True(strings.Contains(err.Error(), `site "b"`))
+	is.True(!a.Config().UseTLS) // a kept its settings
+	_, ok := m.Actor("b")
+	is.True(!ok) // and b was not started
+
+	cert, _ := selfSignedCert(t, "mon")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Leaf.Raw})
+	is.NoErr(os.WriteFile(ca, pemData, 0o600))
+	// The sites file is unchanged, but the sites still have to be applied.
+	waitFor(t, "retry", func() bool { _, ok := m.Actor("b"); return ok && a.Config().UseTLS })
+	got, _ := m.Actor("a")
+	is.True(got == a)
+	is.Equal(got.Config().CAFile, ca)
+	is.NoErr(m.Reload())
+}
+
+func TestSiteManagerStartFailsForUnusableSite(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "sites.yaml")
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	writeSites(t, path, fmt.Sprintf(`
+sites:
+  - {name: a, address: 'mon:6557'}
+  - {name: b, address: 'mon:6557', tls: {ca_file: %q}}
+`, ca))
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	m := NewSiteManager(logger, path, nil, prometheus.NewRegistry())
+	defer m.Close()
+
+	err := m.Start(context.Background())
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `site "b"`))
+	_, ok := m.Actor("a")
+	is.True(!ok) // nothing is left running
+	is.Equal(len(m.Sites().Sites()), 0)
+}
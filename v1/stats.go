@@ -0,0 +1,204 @@
+package livestatus
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StatsFunc identifies how a Stats: aggregate is computed.
+type StatsFunc string
+
+const (
+	StatsCount  StatsFunc = "count" // number of rows matching a filter
+	StatsSum    StatsFunc = "sum"
+	StatsMin    StatsFunc = "min"
+	StatsMax    StatsFunc = "max"
+	StatsAvg    StatsFunc = "avg"
+	StatsStd    StatsFunc = "std"
+	StatsSumInv StatsFunc = "suminv" // sum of 1/x
+	StatsAvgInv StatsFunc = "avginv" // average of 1/x
+)
+
+// StatsAggregate describes one value in each row of a Stats response.
+type StatsAggregate struct {
+	Name   string    // name used in StatsGroup.Values
+	Func   StatsFunc // aggregation function
+	Column string    // aggregated column; empty for StatsCount
+}
+
+var statsDialect = exprDialect{leaf: "Stats", and: "StatsAnd", or: "StatsOr", negate: "StatsNegate"}
+
+// Stats appends a count-by-filter aggregate: "Stats: column <op> value".
+// Its default name is the filter text, e.g. "state = 2"; use StatsAs to rename it.
+func (q *LiveStatusQuery) Stats(column string, op Op, value string) *LiveStatusQuery {
+	line := fmt.Sprintf("Stats: %s %s %s", safeToken(column), op, safeValue(value))
+	q.stats = append(q.stats, line)
+	q.statsAggs = append(q.statsAggs, StatsAggregate{Name: strings.TrimPrefix(line, "Stats: "), Func: StatsCount})
+	return q
+}
+
+// StatsCountExpr appends a single count aggregate named name that counts the
+// rows matching e. The expression is compiled to Stats:/StatsAnd:/StatsOr:/StatsNegate:.
+func (q *LiveStatusQuery) StatsCountExpr(name string, e Expr) *LiveStatusQuery {
+	if e == nil {
+		e = And() // count everything
+	}
+	q.stats = append(q.stats, compileExpr(e, statsDialect)...)
+	q.statsAggs = append(q.statsAggs, StatsAggregate{Name: name, Func: StatsCount})
+	return q
+}
+
+// Aggregate helpers: "Stats: <func> column". Default names are "<func> column".
+
+func (q *LiveStatusQuery) StatsSum(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsSum, column)
+}
+func (q *LiveStatusQuery) StatsMin(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsMin, column)
+}
+func (q *LiveStatusQuery) StatsMax(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsMax, column)
+}
+func (q *LiveStatusQuery) StatsAvg(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsAvg, column)
+}
+func (q *LiveStatusQuery) StatsStd(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsStd, column)
+}
+func (q *LiveStatusQuery) StatsSumInv(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsSumInv, column)
+}
+func (q *LiveStatusQuery) StatsAvgInv(column string) *LiveStatusQuery {
+	return q.statsAggregate(StatsAvgInv, column)
+}
+
+func (q *LiveStatusQuery) statsAggregate(fn StatsFunc, column string) *LiveStatusQuery {
+	column = safeToken(column)
+	q.stats = append(q.stats, fmt.Sprintf("Stats: %s %s", fn, column))
+	q.statsAggs = append(q.statsAggs, StatsAggregate{Name: fmt.Sprintf("%s %s", fn, column), Func: fn, Column: column})
+	return q
+}
+
+// Stats boolean composition, mirroring And/Or/Negate for filters.
+// StatsAnd(n)/StatsOr(n) combine the last n count aggregates into one.
+func (q *LiveStatusQuery) StatsAnd(n int) *LiveStatusQuery {
+	q.stats = append(q.stats, fmt.Sprintf("StatsAnd: %d", n))
+	q.combineStats(n, " and ")
+	return q
+}
+func (q *LiveStatusQuery) StatsOr(n int) *LiveStatusQuery {
+	q.stats = append(q.stats, fmt.Sprintf("StatsOr: %d", n))
+	q.combineStats(n, " or ")
+	return q
+}
+func (q *LiveStatusQuery) StatsNegate() *LiveStatusQuery {
+	q.stats = append(q.stats, "StatsNegate:")
+	if k := len(q.statsAggs); k > 0 {
+		q.statsAggs[k-1].Name = "not (" + q.statsAggs[k-1].Name + ")"
+	}
+	return q
+}
+
+// StatsAs renames the most recently added aggregate.
+func (q *LiveStatusQuery) StatsAs(name string) *LiveStatusQuery {
+	if k := len(q.statsAggs); k > 0 {
+		q.statsAggs[k-1].Name = name
+	}
+	return q
+}
+
+// StatsAggregates returns the aggregates the query will produce, in response order.
+func (q *LiveStatusQuery) StatsAggregates() []StatsAggregate {
+	return append([]StatsAggregate(nil), q.statsAggs...)
+}
+
+// combineStats folds the last n aggregates into one count aggregate.
+// Out-of-range counts are left for the server to reject.
+func (q *LiveStatusQuery) combineStats(n int, sep string) {
+	if n < 0 || n > len(q.statsAggs) {
+		return
+	}
+	start := len(q.statsAggs) - n
+	names := make([]string, 0, n)
+	for _, a := range q.statsAggs[start:] {
+		names = append(names, "("+a.Name+")")
+	}
+	q.statsAggs = append(q.statsAggs[:start], StatsAggregate{Name: strings.Join(names, sep), Func: StatsCount})
+}
+
+// ---------- Decoding ----------
+
+// StatsResult is a decoded Stats response.
+type StatsResult struct {
+	GroupBy    []string         // Columns: used as group keys, in order
+	Aggregates []StatsAggregate // aggregates in response order
+	Groups     []StatsGroup
+}
+
+// StatsGroup holds the aggregates for one combination of group keys.
+// Without Columns: a Stats response has exactly one group with no keys.
+type StatsGroup struct {
+	Keys   []string
+	Values map[string]float64
+}
+
+// Group returns the group with the given keys, or nil if there is none.
+func (r *StatsResult) Group(keys ...string) *StatsGroup {
+	for i := range r.Groups {
+		if slices.Equal(r.Groups[i].Keys, keys) {
+			return &r.Groups[i]
+		}
+	}
+	return nil
+}
+
+// DecodeStats decodes a Stats response body produced by q into named aggregates.
+// Only aggregates added through the Stats builder methods are known; Stats lines
+// injected via Header() are not tracked.
+func DecodeStats(q *LiveStatusQuery, data []byte) (*StatsResult, error) {
+	if q == nil {
+		return nil, fmt.Errorf("query cannot be nil")
+	}
+	if len(q.statsAggs) == 0 {
+		return nil, fmt.Errorf("query has no Stats aggregates")
+	}
+	seen := make(map[string]bool, len(q.statsAggs))
+	for _, a := range q.statsAggs {
+		if seen[a.Name] {
+			return nil, fmt.Errorf("duplicate stats name %q", a.Name)
+		}
+		seen[a.Name] = true
+	}
+	rows, err := parseRows(q.outputFormat, data, q.separators())
+	if err != nil {
+		return nil, err
+	}
+	if q.columnHdrs != nil && *q.columnHdrs && len(rows) > 0 {
+		rows = rows[1:]
+	}
+
+	res := &StatsResult{
+		GroupBy:    append([]string(nil), q.columns...),
+		Aggregates: q.StatsAggregates(),
+	}
+	nKeys, want := len(q.columns), len(q.columns)+len(q.statsAggs)
+	for i, row := range rows {
+		if len(row) != want {
+			return nil, fmt.Errorf("stats row %d: got %d values, want %d", i, len(row), want)
+		}
+		g := StatsGroup{Keys: make([]string, nKeys), Values: make(map[string]float64, len(q.statsAggs))}
+		for k := 0; k < nKeys; k++ {
+			g.Keys[k] = cellString(row[k])
+		}
+		for k, a := range q.statsAggs {
+			f, err := cellFloat(row[nKeys+k])
+			if err != nil {
+				return nil, fmt.Errorf("stats row %d, %q: %w", i, a.Name, err)
+			}
+			g.Values[a.Name] = f
+		}
+		res.Groups = append(res.Groups, g)
+	}
+	return res, nil
+}
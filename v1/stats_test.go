@@ -0,0 +1,102 @@
+package livestatus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStatsBuild(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("services"), "host_groups").
+		FilterEqual("active_checks_enabled", "1").
+		Stats("state", OpEq, "0").StatsAs("ok").
+		StatsCountExpr("crit_unacked", And(Eq("state", "2"), Eq("acknowledged", "0"))).
+		StatsAvg("latency").
+		StatsSumInv("execution_time")
+
+	got := strings.Split(strings.TrimSuffix(q.Build(), "\n"), "\n")
+	want := []string{
+		"GET services",
+		"Columns: host_groups",
+		"Filter: active_checks_enabled = 1",
+		"Stats: state = 0",
+		"Stats: state = 2",
+		"Stats: acknowledged = 0",
+		"StatsAnd: 2",
+		"Stats: avg latency",
+		"Stats: suminv execution_time",
+	}
+	is.Equal(got, want)
+
+	aggs := q.StatsAggregates()
+	is.Equal(len(aggs), 4)
+	is.Equal(aggs[0], StatsAggregate{Name: "ok", Func: StatsCount})
+	is.Equal(aggs[1].Name, "crit_unacked")
+	is.Equal(aggs[2], StatsAggregate{Name: "avg latency", Func: StatsAvg, Column: "latency"})
+	is.Equal(aggs[3].Func, StatsSumInv)
+}
+
+func TestStatsRawComposition(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("hosts")).
+		Stats("state", OpEq, "1").
+		Stats("state", OpEq, "2").
+		StatsOr(2).
+		StatsNegate().
+		StatsMax("latency")
+
+	aggs := q.StatsAggregates()
+	is.Equal(len(aggs), 2)
+	is.Equal(aggs[0].Name, "not ((state = 1) or (state = 2))")
+	is.Equal(aggs[0].Func, StatsCount)
+	is.Equal(aggs[1].Name, "max latency")
+}
+
+func TestDecodeStatsGrouped(t *testing.T) {
+	is := is.New(t)
+
+	q := NewLiveStatusQuery(Table("services"), "host_name").
+		Stats("state", OpEq, "0").StatsAs("ok").
+		Stats("state", OpEq, "2").StatsAs("crit").
+		StatsAvg("latency")
+
+	csv := []byte("web01;10;1;0.25\nweb02;3;0;1.5\n")
+	res, err := DecodeStats(q, csv)
+	is.NoErr(err)
+	is.Equal(res.GroupBy, []string{"host_name"})
+	is.Equal(len(res.Groups), 2)
+	g := res.Group("web02")
+	is.True(g != nil)
+	is.Equal(g.Values["ok"], 3.0)
+	is.Equal(g.Values["crit"], 0.0)
+	is.Equal(g.Values["avg latency"], 1.5)
+
+	q.OutputFormat(OutputJSON)
+	res, err = DecodeStats(q, []byte(`[["web01",10,1,0.25],["web02",3,0,1.5]]`))
+	is.NoErr(err)
+	is.Equal(res.Group("web01").Values["crit"], 1.0)
+
+	q.OutputFormat(OutputPY)
+	res, err = DecodeStats(q, []byte(`[[u'web01',10,1,0.25],[u'web02',3,0,1.5]]`))
+	is.NoErr(err)
+	is.Equal(res.Group("web01").Values["avg latency"], 0.25)
+}
+
+func TestDecodeStatsErrors(t *testing.T) {
+	is := is.New(t)
+
+	_, err := DecodeStats(NewLiveStatusQuery(Table("hosts")), []byte("1\n"))
+	is.True(err != nil) // no aggregates
+
+	q := NewLiveStatusQuery(Table("hosts")).StatsSum("latency").StatsSum("latency")
+	_, err = DecodeStats(q, []byte("1;2\n"))
+	is.True(err != nil) // duplicate names
+
+	q = NewLiveStatusQuery(Table("hosts")).StatsSum("latency")
+	_, err = DecodeStats(q, []byte("1;2\n"))
+	is.True(err != nil) // wrong width
+}
@@ -0,0 +1,173 @@
+package livestatus
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// singularHeaders may appear at most once in a request.
+var singularHeaders = []string{
+	"Columns", "Limit", "AuthUser", "Timelimit", "Separators", "Localtime",
+	"KeepAlive", "ResponseHeader", "OutputFormat", "ColumnHeaders",
+	"WaitObject", "WaitTrigger", "WaitTimeout",
+}
+
+// Validate checks the request for malformed or contradictory headers without
+// consulting a schema: negative counts and timeouts, boolean stacks that pop
+// more entries than were pushed, unknown WaitTrigger/OutputFormat values,
+// singular headers given twice (e.g. via Header) and so on. It returns a
+// *ValidationError listing every problem found, or nil.
+func (q *LiveStatusQuery) Validate() error {
+	if problems := q.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// BuildChecked is Build for callers that want malformed requests rejected
+// instead of sent; the error is the one returned by Validate.
+func (q *LiveStatusQuery) BuildChecked() (string, error) {
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+	return q.Build(), nil
+}
+
+// problems lists the structural problems found by Validate.
+func (q *LiveStatusQuery) problems() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if q.table == "" || strings.ContainsAny(string(q.table), " \t\r\n") {
+		addf("invalid table name %q", q.table)
+	}
+
+	// Headers set through fields count alongside those added with Header.
+	seen := map[string]int{}
+	if len(q.columns) > 0 {
+		seen["Columns"]++
+	}
+	if q.columnHdrs != nil {
+		seen["ColumnHeaders"]++
+	}
+	if q.outputFormat != "" {
+		seen["OutputFormat"]++
+		if !slices.Contains(knownOutputFormats, q.outputFormat) {
+			addf("unknown OutputFormat %q", q.outputFormat)
+		}
+	}
+
+	nonNegative := func(key, value string) {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			addf("%s: expected a non-negative integer, got %q", key, value)
+		}
+	}
+	onOff := func(key, value string) {
+		if value != "on" && value != "off" {
+			addf("%s: expected on or off, got %q", key, value)
+		}
+	}
+
+	for _, line := range q.headers {
+		key, value := headerKV(line)
+		seen[key]++
+		switch key {
+		case "Limit", "Timelimit", "WaitTimeout":
+			nonNegative(key, value)
+		case "Localtime":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				addf("Localtime: invalid timestamp %q", value)
+			}
+		case "AuthUser", "WaitObject":
+			if strings.TrimSpace(value) == "" {
+				addf("%s needs a value", key)
+			}
+		case "WaitTrigger":
+			if !slices.Contains(knownWaitTriggers, value) {
+				addf("unknown WaitTrigger %q (want one of %s)", value, strings.Join(knownWaitTriggers, ", "))
+			}
+		case "KeepAlive", "ColumnHeaders":
+			onOff(key, value)
+		case "ResponseHeader":
+			if value != "fixed16" && value != "off" {
+				addf("ResponseHeader: expected fixed16 or off, got %q", value)
+			}
+		case "OutputFormat":
+			if !slices.Contains(knownOutputFormats, OutputFormat(value)) {
+				addf("unknown OutputFormat %q", value)
+			}
+		case "Separators":
+			if _, err := parseSeparators(value); err != nil {
+				addf("Separators: %v", err)
+			}
+		}
+	}
+	for _, key := range singularHeaders {
+		if seen[key] > 1 {
+			addf("%s given %d times", key, seen[key])
+		}
+	}
+
+	return append(problems, stackProblems(q)...)
+}
+
+// stackProblems checks And/Or/Negate (and their Stats and WaitCondition
+// counterparts) against the number of operands actually on each stack.
+func stackProblems(q *LiveStatusQuery) []string {
+	var problems []string
+	check := func(lines []string, d exprDialect) {
+		depth := 0
+		for _, line := range lines {
+			key, value := headerKV(line)
+			switch key {
+			case d.leaf:
+				depth++
+			case d.and, d.or:
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					problems = append(problems, fmt.Sprintf("%s: invalid count %q", key, value))
+					continue
+				}
+				if n > depth {
+					problems = append(problems, fmt.Sprintf("%s: %d exceeds the %d entries on the %s stack", key, n, depth, d.leaf))
+					continue
+				}
+				depth = depth - n + 1
+			case d.negate:
+				if depth == 0 {
+					problems = append(problems, fmt.Sprintf("%s: empty %s stack", key, d.leaf))
+				}
+			}
+		}
+	}
+	check(q.filters, filterDialect)
+	check(q.stats, statsDialect)
+	check(q.headers, waitDialect)
+	return problems
+}
+
+// parseSeparators parses the value of a "Separators:" header: four decimal
+// byte values, of which the row and field separators must differ.
+func parseSeparators(value string) (separators, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return separators{}, fmt.Errorf("expected 4 byte values, got %q", value)
+	}
+	var b [4]byte
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || n > 255 {
+			return separators{}, fmt.Errorf("invalid byte value %q", f)
+		}
+		b[i] = byte(n)
+	}
+	seps := separators{row: b[0], field: b[1], list: b[2], hostService: b[3]}
+	if seps.row == seps.field {
+		return separators{}, fmt.Errorf("row and field separator are both %d", seps.row)
+	}
+	return seps, nil
+}